@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/dsdashun/ccctx/config"
+	"github.com/spf13/cobra"
+)
+
+type addOptions struct {
+	extends         string
+	provider        string
+	baseURL         string
+	authToken       string
+	awsRegion       string
+	awsProfile      string
+	vertexRegion    string
+	vertexProjectID string
+	model           string
+	haikuModel      string
+	sonnetModel     string
+	opusModel       string
+	env             []string
+}
+
+var addOpts addOptions
+
+var AddCmd = &cobra.Command{
+	Use:   "add <context>",
+	Short: "Add a context",
+	Long:  "Add a context to the configuration file. The fields its provider needs, base_url and auth_token for anthropic, aws_region for bedrock, vertex_region and vertex_project_id for vertex, are required unless the context extends another one or [defaults] supplies them. Prefer an 'env:', 'file:', 'cmd:' or 'vault:' reference over a literal token, which would end up in your shell history.",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		os.Exit(addRun(args[0], addOpts))
+	},
+}
+
+func init() {
+	flags := AddCmd.Flags()
+	flags.StringVar(&addOpts.extends, "extends", "", "context to inherit unset fields from")
+	flags.StringVar(&addOpts.provider, "provider", "", "provider: anthropic (the default), bedrock or vertex")
+	flags.StringVar(&addOpts.baseURL, "base-url", "", "API base URL")
+	flags.StringVar(&addOpts.authToken, "auth-token", "", "auth token or secret reference such as env:NAME")
+	flags.StringVar(&addOpts.awsRegion, "aws-region", "", "AWS region, for the bedrock provider")
+	flags.StringVar(&addOpts.awsProfile, "aws-profile", "", "AWS profile, for the bedrock provider")
+	flags.StringVar(&addOpts.vertexRegion, "vertex-region", "", "Google Cloud region, for the vertex provider")
+	flags.StringVar(&addOpts.vertexProjectID, "vertex-project-id", "", "Google Cloud project ID, for the vertex provider")
+	flags.StringVar(&addOpts.model, "model", "", "default model")
+	flags.StringVar(&addOpts.haikuModel, "haiku-model", "", "Haiku-class model")
+	flags.StringVar(&addOpts.sonnetModel, "sonnet-model", "", "Sonnet-class model")
+	flags.StringVar(&addOpts.opusModel, "opus-model", "", "Opus-class model")
+	flags.StringArrayVar(&addOpts.env, "env", nil, "extra environment variable as NAME=VALUE (repeatable)")
+	registerModelCompletions(AddCmd)
+	_ = AddCmd.RegisterFlagCompletionFunc("provider", cobra.FixedCompletions(config.Providers, cobra.ShellCompDirectiveNoFileComp))
+	_ = AddCmd.RegisterFlagCompletionFunc("extends", func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+		return contextNames(toComplete), cobra.ShellCompDirectiveNoFileComp
+	})
+}
+
+func addRun(name string, opts addOptions) int {
+	fields := map[string]string{}
+	for key, value := range map[string]string{
+		"extends":           opts.extends,
+		"provider":          opts.provider,
+		"base_url":          opts.baseURL,
+		"auth_token":        opts.authToken,
+		"aws_region":        opts.awsRegion,
+		"aws_profile":       opts.awsProfile,
+		"vertex_region":     opts.vertexRegion,
+		"vertex_project_id": opts.vertexProjectID,
+		"model":             opts.model,
+		"haiku_model":       opts.haikuModel,
+		"sonnet_model":      opts.sonnetModel,
+		"opus_model":        opts.opusModel,
+	} {
+		if value != "" {
+			fields[key] = value
+		}
+	}
+	for _, pair := range opts.env {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			fmt.Fprintf(os.Stderr, "Error: invalid --env '%s': expected NAME=VALUE\n", pair)
+			return 1
+		}
+		fields["env."+key] = value
+	}
+
+	if opts.extends == "" {
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		provider := opts.provider
+		if provider == "" {
+			provider, _ = cfg.Defaults["provider"].(string)
+		}
+		if provider == "" {
+			provider = config.ProviderAnthropic
+		}
+		required, err := config.RequiredFields(provider)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		for _, key := range required {
+			if _, fromDefaults := cfg.Defaults[key]; fields[key] == "" && !fromDefaults {
+				fmt.Fprintf(os.Stderr, "Error: --%s is required unless --extends is given\n", strings.ReplaceAll(key, "_", "-"))
+				return 1
+			}
+		}
+	}
+
+	if err := config.AddContext(name, fields); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Context '%s' added.\n", name)
+	return 0
+}
@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dsdashun/ccctx/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddRun(t *testing.T) {
+	tests := []struct {
+		name       string
+		configTOML string
+		opts       addOptions
+		wantCode   int
+		want       *config.Context
+	}{
+		{
+			name:     "base url and token",
+			opts:     addOptions{baseURL: "https://api.example.com", authToken: "env:NEW_TOKEN", env: []string{"API_TIMEOUT=600"}},
+			wantCode: 0,
+			want: &config.Context{
+				BaseURL:   "https://api.example.com",
+				AuthToken: "new-token",
+				Env:       map[string]string{"API_TIMEOUT": "600"},
+				Refs:      map[string]string{"auth_token": "env:NEW_TOKEN"},
+			},
+		},
+		{
+			name:     "extends another context",
+			opts:     addOptions{extends: "work", model: "new-model"},
+			wantCode: 0,
+			want: &config.Context{
+				Extends:   "work",
+				BaseURL:   "https://work.example.com",
+				AuthToken: "work-token",
+				Model:     "new-model",
+			},
+		},
+		{
+			name:       "base url from defaults",
+			configTOML: "[defaults]\nbase_url = \"https://defaults.example.com\"\n",
+			opts:       addOptions{authToken: "env:NEW_TOKEN"},
+			wantCode:   0,
+			want: &config.Context{
+				BaseURL:   "https://defaults.example.com",
+				AuthToken: "new-token",
+				Refs:      map[string]string{"auth_token": "env:NEW_TOKEN"},
+			},
+		},
+		{
+			name:     "bedrock needs a region but no base url or token",
+			opts:     addOptions{provider: "bedrock", awsRegion: "us-east-1", awsProfile: "work"},
+			wantCode: 0,
+			want: &config.Context{
+				Provider:   "bedrock",
+				AWSRegion:  "us-east-1",
+				AWSProfile: "work",
+			},
+		},
+		{
+			name:     "vertex without a project",
+			opts:     addOptions{provider: "vertex", vertexRegion: "us-east5"},
+			wantCode: 1,
+		},
+		{
+			name:     "unknown provider",
+			opts:     addOptions{provider: "azure", baseURL: "https://api.example.com", authToken: "env:NEW_TOKEN"},
+			wantCode: 1,
+		},
+		{
+			name:     "missing auth token",
+			opts:     addOptions{baseURL: "https://api.example.com"},
+			wantCode: 1,
+		},
+		{
+			name:     "malformed env",
+			opts:     addOptions{extends: "work", env: []string{"API_TIMEOUT"}},
+			wantCode: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "config.toml")
+			configTOML := tt.configTOML + "[context.work]\nbase_url = \"https://work.example.com\"\nauth_token = \"work-token\"\n"
+			require.NoError(t, os.WriteFile(configPath, []byte(configTOML), 0600))
+			t.Setenv("CCCTX_CONFIG_PATH", configPath)
+			t.Setenv("NEW_TOKEN", "new-token")
+
+			code := addRun("new", tt.opts)
+			assert.Equal(t, tt.wantCode, code)
+
+			ctx, err := config.GetContext("new")
+			if tt.want == nil {
+				assert.Error(t, err, "failed add must not create the context")
+				return
+			}
+			require.NoError(t, err)
+			ctx.Sources = nil
+			assert.Equal(t, tt.want, ctx)
+		})
+	}
+}
@@ -0,0 +1,234 @@
+package cmd
+
+import (
+	"context"
+	"maps"
+	"os/exec"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/dsdashun/ccctx/config"
+	"github.com/spf13/cobra"
+)
+
+// modelFlags are the run and exec flags that take a model name.
+var modelFlags = []string{"--model", "--haiku-model", "--sonnet-model", "--opus-model", "--small-fast-model"}
+
+// runFlags are the flags run and exec recognize before '--', with their
+// completion descriptions.
+var runFlags = []cobra.Completion{
+	cobra.CompletionWithDesc("--model", "override the model"),
+	cobra.CompletionWithDesc("--haiku-model", "override the Haiku-class model"),
+	cobra.CompletionWithDesc("--sonnet-model", "override the Sonnet-class model"),
+	cobra.CompletionWithDesc("--opus-model", "override the Opus-class model"),
+	cobra.CompletionWithDesc("--small-fast-model", "alias for --haiku-model"),
+	cobra.CompletionWithDesc("--select", "open the interactive selector"),
+	cobra.CompletionWithDesc("--dry-run", "print what would run instead of running it"),
+	cobra.CompletionWithDesc("--replace", "replace ccctx with the command instead of running it as a child"),
+	cobra.CompletionWithDesc("--help", "show help"),
+	cobra.CompletionWithDesc("--", "end of ccctx options"),
+}
+
+// contextNames returns the configured contexts starting with prefix, described
+// by their description when they have one. Errors yield no completions rather
+// than breaking the shell.
+func contextNames(prefix string) []cobra.Completion {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil
+	}
+	names, err := config.ListContexts()
+	if err != nil {
+		return nil
+	}
+	var completions []cobra.Completion
+	for _, name := range names {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		if desc := cfg.Contexts[name].Description; desc != "" {
+			completions = append(completions, cobra.CompletionWithDesc(name, desc))
+		} else {
+			completions = append(completions, name)
+		}
+	}
+	return completions
+}
+
+// modelNames returns the models that start with prefix among those the
+// contexts set or inherit, and the model aliases, described by the model
+// they stand for. They are sorted and without duplicates.
+func modelNames(prefix string) []cobra.Completion {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil
+	}
+	var models []string
+	aliases := maps.Clone(cfg.Models)
+	if aliases == nil {
+		aliases = map[string]string{}
+	}
+	for name := range cfg.Contexts {
+		ctx, err := cfg.Resolve(name)
+		if err != nil {
+			continue
+		}
+		models = append(models, ctx.Model, ctx.SmallFastModel, ctx.HaikuModel, ctx.SonnetModel, ctx.OpusModel)
+		for alias, model := range ctx.Models {
+			if _, exists := aliases[alias]; !exists {
+				aliases[alias] = model
+			}
+		}
+	}
+	for alias := range aliases {
+		models = append(models, alias)
+	}
+	slices.Sort(models)
+	models = slices.Compact(models)
+
+	var completions []cobra.Completion
+	for _, model := range models {
+		if model == "" || !strings.HasPrefix(model, prefix) {
+			continue
+		}
+		if target, isAlias := aliases[model]; isAlias {
+			completions = append(completions, cobra.CompletionWithDesc(model, "alias for "+target))
+		} else {
+			completions = append(completions, model)
+		}
+	}
+	return completions
+}
+
+// completeContext completes a context name as the first argument only.
+func completeContext(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return contextNames(toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeContexts completes context names for commands that take several,
+// leaving out those already given.
+func completeContexts(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	completions := slices.DeleteFunc(contextNames(toComplete), func(c cobra.Completion) bool {
+		name, _, _ := strings.Cut(c, "\t")
+		return slices.Contains(args, name)
+	})
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeModel completes the value of a model flag.
+func completeModel(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	return modelNames(toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// registerModelCompletions completes the model flags of a command that lets
+// cobra parse its flags.
+func registerModelCompletions(cmd *cobra.Command) {
+	for _, flag := range []string{"model", "haiku-model", "sonnet-model", "opus-model"} {
+		if cmd.Flags().Lookup(flag) != nil {
+			_ = cmd.RegisterFlagCompletionFunc(flag, completeModel)
+		}
+	}
+}
+
+// completeRunArgs completes run and exec, which parse their own flags. Before
+// '--' it completes ccctx's flags, model flag values and the context name.
+// After '--' the words belong to the target command: for run, claude's own
+// options and commands are completed; otherwise completion is left to the
+// shell's default.
+func completeRunArgs(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	if i := slices.Index(args, "--"); i >= 0 {
+		if cmd.Name() == "run" {
+			return claudeCompletions(args[i+1:], toComplete)
+		}
+		return nil, cobra.ShellCompDirectiveDefault
+	}
+	if len(args) > 0 && slices.Contains(modelFlags, args[len(args)-1]) {
+		return modelNames(toComplete), cobra.ShellCompDirectiveNoFileComp
+	}
+
+	haveContext := false
+	for i := 0; i < len(args); i++ {
+		switch {
+		case slices.Contains(modelFlags, args[i]):
+			i++
+		case !strings.HasPrefix(args[i], "-"):
+			haveContext = true
+		}
+	}
+
+	if strings.HasPrefix(toComplete, "-") {
+		var completions []cobra.Completion
+		for _, flag := range runFlags {
+			if strings.HasPrefix(flag, toComplete) {
+				completions = append(completions, flag)
+			}
+		}
+		return completions, cobra.ShellCompDirectiveNoFileComp
+	}
+	if haveContext {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return contextNames(toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// claudeHelpTimeout bounds how long completion waits for 'claude --help'.
+var claudeHelpTimeout = 3 * time.Second
+
+// claudeHelpEntry matches an entry of the Options or Commands section of
+// 'claude --help', such as "  -p, --print        Print response and exit"
+// or "  mcp [options]   Configure MCP servers", capturing the short name, the
+// name and the description.
+var claudeHelpEntry = regexp.MustCompile(`^  (?:(-\w), )?(-?-?[\w][\w-]*)(?: [<\[][^>\]]*[>\]])*\s{2,}(\S.*)$`)
+
+// claudeCompletions completes the arguments given to claude after '--' from
+// the options and commands 'claude --help' lists: options when toComplete
+// starts with '-', commands as the first argument. Other words, such as a
+// prompt or file, are left to the shell's default, as is everything when
+// claude cannot be run.
+func claudeCompletions(args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	path, err := exec.LookPath("claude")
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveDefault
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), claudeHelpTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, path, "--help").Output()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveDefault
+	}
+
+	options := strings.HasPrefix(toComplete, "-")
+	if !options && len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveDefault
+	}
+	want := "Commands:"
+	if options {
+		want = "Options:"
+	}
+	var completions []cobra.Completion
+	section := ""
+	for _, line := range strings.Split(string(out), "\n") {
+		if strings.HasSuffix(line, ":") && !strings.HasPrefix(line, " ") {
+			section = line
+			continue
+		}
+		m := claudeHelpEntry.FindStringSubmatch(line)
+		if m == nil || section != want {
+			continue
+		}
+		for _, name := range []string{m[1], m[2]} {
+			if name != "" && strings.HasPrefix(name, toComplete) {
+				completions = append(completions, cobra.CompletionWithDesc(name, m[3]))
+			}
+		}
+	}
+	if options {
+		return completions, cobra.ShellCompDirectiveNoFileComp
+	}
+	return completions, cobra.ShellCompDirectiveDefault
+}
@@ -0,0 +1,281 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompleteRunArgs(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.toml")
+	configTOML := `[defaults]
+model = "default-model"
+
+[models]
+fast = "claude-haiku-4-5"
+
+[context.work]
+description = "Work account"
+base_url = "https://work.example.com"
+auth_token = "token"
+opus_model = "work-opus"
+
+[context.personal]
+base_url = "https://personal.example.com"
+auth_token = "token"
+model = "personal-model"
+
+[context.personal.models]
+deep = "personal-opus"
+
+[context.broken]
+extends = "missing"
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(configTOML), 0600))
+	t.Setenv("CCCTX_CONFIG_PATH", configPath)
+	// Without claude on PATH, words after '--' are left to the shell
+	t.Setenv("PATH", t.TempDir())
+
+	tests := []struct {
+		name          string
+		args          []string
+		toComplete    string
+		want          []cobra.Completion
+		wantDirective cobra.ShellCompDirective
+	}{
+		{
+			name:          "context names",
+			want:          []cobra.Completion{"broken", "personal", "work\tWork account"},
+			wantDirective: cobra.ShellCompDirectiveNoFileComp,
+		},
+		{
+			name:          "context names by prefix",
+			toComplete:    "p",
+			want:          []cobra.Completion{"personal"},
+			wantDirective: cobra.ShellCompDirectiveNoFileComp,
+		},
+		{
+			name:          "context names after flags",
+			args:          []string{"--select", "--model", "x"},
+			toComplete:    "w",
+			want:          []cobra.Completion{"work\tWork account"},
+			wantDirective: cobra.ShellCompDirectiveNoFileComp,
+		},
+		{
+			name:          "nothing after the context",
+			args:          []string{"work"},
+			wantDirective: cobra.ShellCompDirectiveNoFileComp,
+		},
+		{
+			name:          "model values across contexts",
+			args:          []string{"work", "--opus-model"},
+			want:          []cobra.Completion{"deep\talias for personal-opus", "default-model", "fast\talias for claude-haiku-4-5", "personal-model", "work-opus"},
+			wantDirective: cobra.ShellCompDirectiveNoFileComp,
+		},
+		{
+			name:          "model values by prefix",
+			args:          []string{"--model"},
+			toComplete:    "work",
+			want:          []cobra.Completion{"work-opus"},
+			wantDirective: cobra.ShellCompDirectiveNoFileComp,
+		},
+		{
+			name:          "flags",
+			toComplete:    "--s",
+			want:          []cobra.Completion{"--sonnet-model\toverride the Sonnet-class model", "--small-fast-model\talias for --haiku-model", "--select\topen the interactive selector"},
+			wantDirective: cobra.ShellCompDirectiveNoFileComp,
+		},
+		{
+			name:          "after separator without claude defers to the shell",
+			args:          []string{"work", "--"},
+			toComplete:    "--m",
+			wantDirective: cobra.ShellCompDirectiveDefault,
+		},
+		{
+			name:          "model flag after separator is not ours",
+			args:          []string{"--", "--model"},
+			wantDirective: cobra.ShellCompDirectiveDefault,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, directive := completeRunArgs(RunCmd, tt.args, tt.toComplete)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantDirective, directive)
+		})
+	}
+}
+
+func TestCompleteRunArgs_Claude(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake claude needs sh")
+	}
+	const help = `Usage: claude [options] [command] [prompt]
+
+Arguments:
+  prompt                                Your prompt
+
+Options:
+  -d, --debug [filter]                  Enable debug mode
+  --mcp-config <configs...>             Load MCP servers from JSON files
+  -p, --print                           Print response and exit (useful for
+                                        pipes)
+  -h, --help                            Display help for command
+
+Commands:
+  config                                Manage configuration
+  mcp                                   Configure and manage MCP servers
+  install [options] [target]            Install Claude Code native build
+`
+	bin := t.TempDir()
+	helpPath := filepath.Join(bin, "help.txt")
+	require.NoError(t, os.WriteFile(helpPath, []byte(help), 0600))
+	script := "#!/bin/sh\nwhile IFS= read -r line; do echo \"$line\"; done < '" + helpPath + "'\n"
+	require.NoError(t, os.WriteFile(filepath.Join(bin, "claude"), []byte(script), 0755))
+	t.Setenv("PATH", bin)
+
+	tests := []struct {
+		name          string
+		cmd           *cobra.Command
+		args          []string
+		toComplete    string
+		want          []cobra.Completion
+		wantDirective cobra.ShellCompDirective
+	}{
+		{
+			name:          "claude options",
+			cmd:           RunCmd,
+			args:          []string{"work", "--"},
+			toComplete:    "--m",
+			want:          []cobra.Completion{"--mcp-config\tLoad MCP servers from JSON files"},
+			wantDirective: cobra.ShellCompDirectiveNoFileComp,
+		},
+		{
+			name:       "short and long names",
+			cmd:        RunCmd,
+			args:       []string{"--"},
+			toComplete: "-",
+			want: []cobra.Completion{
+				"-d\tEnable debug mode", "--debug\tEnable debug mode",
+				"--mcp-config\tLoad MCP servers from JSON files",
+				"-p\tPrint response and exit (useful for", "--print\tPrint response and exit (useful for",
+				"-h\tDisplay help for command", "--help\tDisplay help for command",
+			},
+			wantDirective: cobra.ShellCompDirectiveNoFileComp,
+		},
+		{
+			name:          "claude commands as the first argument",
+			cmd:           RunCmd,
+			args:          []string{"work", "--"},
+			toComplete:    "i",
+			want:          []cobra.Completion{"install\tInstall Claude Code native build"},
+			wantDirective: cobra.ShellCompDirectiveDefault,
+		},
+		{
+			name:          "later words are left to the shell",
+			cmd:           RunCmd,
+			args:          []string{"work", "--", "mcp"},
+			wantDirective: cobra.ShellCompDirectiveDefault,
+		},
+		{
+			name:          "exec targets are not claude",
+			cmd:           ExecCmd,
+			args:          []string{"work", "--"},
+			toComplete:    "--m",
+			wantDirective: cobra.ShellCompDirectiveDefault,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, directive := completeRunArgs(tt.cmd, tt.args, tt.toComplete)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantDirective, directive)
+		})
+	}
+}
+
+func TestCompleteField(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.toml")
+	configTOML := `[context.work]
+base_url = "https://work.example.com"
+auth_token = "token"
+model = "work-model"
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(configTOML), 0600))
+	t.Setenv("CCCTX_CONFIG_PATH", configPath)
+
+	tests := []struct {
+		name          string
+		cmd           *cobra.Command
+		args          []string
+		toComplete    string
+		want          []cobra.Completion
+		wantDirective cobra.ShellCompDirective
+	}{
+		{
+			name:          "context",
+			cmd:           SetCmd,
+			want:          []cobra.Completion{"work"},
+			wantDirective: cobra.ShellCompDirectiveNoFileComp,
+		},
+		{
+			name:          "field",
+			cmd:           SetCmd,
+			args:          []string{"work"},
+			toComplete:    "s",
+			want:          []cobra.Completion{"small_fast_model", "sonnet_model"},
+			wantDirective: cobra.ShellCompDirectiveNoFileComp,
+		},
+		{
+			name:          "env field leaves room for the name",
+			cmd:           UnsetCmd,
+			args:          []string{"work"},
+			toComplete:    "e",
+			want:          []cobra.Completion{"extends", "env."},
+			wantDirective: cobra.ShellCompDirectiveNoFileComp,
+		},
+		{
+			name:          "env field alone",
+			cmd:           SetCmd,
+			args:          []string{"work"},
+			toComplete:    "en",
+			want:          []cobra.Completion{"env."},
+			wantDirective: cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace,
+		},
+		{
+			name:          "model value",
+			cmd:           SetCmd,
+			args:          []string{"work", "opus_model"},
+			want:          []cobra.Completion{"work-model"},
+			wantDirective: cobra.ShellCompDirectiveNoFileComp,
+		},
+		{
+			name:          "extends value",
+			cmd:           SetCmd,
+			args:          []string{"work", "extends"},
+			want:          []cobra.Completion{"work"},
+			wantDirective: cobra.ShellCompDirectiveNoFileComp,
+		},
+		{
+			name:          "unset takes no value",
+			cmd:           UnsetCmd,
+			args:          []string{"work", "model"},
+			wantDirective: cobra.ShellCompDirectiveNoFileComp,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, directive := completeField(tt.cmd, tt.args, tt.toComplete)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantDirective, directive)
+		})
+	}
+}
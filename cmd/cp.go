@@ -0,0 +1,25 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/dsdashun/ccctx/config"
+	"github.com/spf13/cobra"
+)
+
+var CpCmd = &cobra.Command{
+	Use:               "cp <context> <new-name>",
+	Aliases:           []string{"copy"},
+	Short:             "Copy a context",
+	Long:              "Copy a context's tables in the configuration file under a new name.",
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeContext,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := config.CopyContext(args[0], args[1]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Context '%s' copied to '%s'.\n", args[0], args[1])
+	},
+}
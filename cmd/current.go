@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var CurrentCmd = &cobra.Command{
+	Use:   "current",
+	Short: "Print the current context",
+	Long:  "Print the context run and exec use when none is given: the one named by a .ccctx.toml or .ccctx file in or above the working directory, else the one set with 'ccctx use'. Where the choice came from is printed on stderr.",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		current, project, err := defaultContext()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if current == "" {
+			fmt.Fprintln(os.Stderr, "Error: no current context set; run 'ccctx use <context>'")
+			os.Exit(1)
+		}
+		fmt.Println(current)
+		if project != nil {
+			fmt.Fprintf(os.Stderr, "(set by %s)\n", project.Path)
+		} else {
+			fmt.Fprintln(os.Stderr, "(set by 'ccctx use')")
+		}
+	},
+}
@@ -0,0 +1,306 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"os/exec"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/dsdashun/ccctx/config"
+	"github.com/dsdashun/ccctx/internal/runner"
+	"github.com/spf13/cobra"
+)
+
+var doctorOutput string
+
+var DoctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check the config and environment for problems",
+	Long: `Check that the config file parses and is private, that it has no unknown keys, that claude is on PATH, and that every context has a valid base_url and an auth_token whose env: and file: references resolve. Other references, such as cmd: and vault:, are not resolved.
+
+Each check passes, warns or fails; doctor exits with status 1 when any check fails.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		os.Exit(doctorRun(doctorOutput, os.Stdout))
+	},
+}
+
+func init() {
+	DoctorCmd.Flags().StringVarP(&doctorOutput, "output", "o", "", "output format: json")
+	_ = DoctorCmd.RegisterFlagCompletionFunc("output", cobra.FixedCompletions([]cobra.Completion{"json"}, cobra.ShellCompDirectiveNoFileComp))
+}
+
+// Outcomes of a doctor check, from best to worst.
+const (
+	checkPass = "pass"
+	checkWarn = "warn"
+	checkFail = "fail"
+)
+
+// claudeVersionTimeout bounds how long 'claude --version' may take.
+var claudeVersionTimeout = 10 * time.Second
+
+type doctorCheck struct {
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
+
+// doctorSection groups the checks about one subject: the config file, the
+// claude binary or a context. Its status is that of its worst check.
+type doctorSection struct {
+	Name   string        `json:"name"`
+	Status string        `json:"status"`
+	Checks []doctorCheck `json:"checks"`
+}
+
+func (s *doctorSection) add(status, format string, args ...any) {
+	s.Checks = append(s.Checks, doctorCheck{status, fmt.Sprintf(format, args...)})
+	if s.Status == "" || severity(status) > severity(s.Status) {
+		s.Status = status
+	}
+}
+
+func severity(status string) int {
+	return slices.Index([]string{checkPass, checkWarn, checkFail}, status)
+}
+
+type doctorReport struct {
+	Config   doctorSection   `json:"config"`
+	Claude   doctorSection   `json:"claude"`
+	Contexts []doctorSection `json:"contexts"`
+	OK       bool            `json:"ok"`
+}
+
+func doctorRun(output string, stdout io.Writer) int {
+	if output != "" && output != "json" {
+		fmt.Fprintf(os.Stderr, "Error: unknown output format '%s': use json\n", output)
+		return 1
+	}
+	configPath, err := config.GetConfigPath()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	report := doctorReport{
+		Config:   doctorSection{Name: configPath},
+		Claude:   checkClaude(),
+		Contexts: []doctorSection{},
+	}
+	cfg := checkConfigFile(&report.Config, configPath)
+	if cfg != nil {
+		for _, name := range slices.Sorted(maps.Keys(cfg.Contexts)) {
+			report.Contexts = append(report.Contexts, checkContext(cfg, name))
+		}
+	}
+	report.OK = report.Config.Status != checkFail && report.Claude.Status != checkFail &&
+		!slices.ContainsFunc(report.Contexts, func(s doctorSection) bool { return s.Status == checkFail })
+
+	if output == "json" {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		fmt.Fprintln(stdout, string(data))
+	} else {
+		printDoctorReport(report, stdout)
+	}
+	if !report.OK {
+		return 1
+	}
+	return 0
+}
+
+// checkConfigFile checks the config file itself, returning it parsed, or nil
+// when it cannot be read.
+func checkConfigFile(s *doctorSection, path string) *config.Config {
+	info, err := os.Stat(path)
+	if err != nil {
+		s.add(checkFail, "%v", err)
+		return nil
+	}
+	cfg, err := config.LoadFile(path)
+	if err != nil {
+		s.add(checkFail, "%v", err)
+		return nil
+	}
+	s.add(checkPass, "parsed %s", countOf(len(cfg.Contexts), "context"))
+
+	// Windows does not report meaningful permission bits
+	if perm := info.Mode().Perm(); runtime.GOOS != "windows" && perm&0077 != 0 {
+		s.add(checkWarn, "accessible by other users (mode %04o); run 'chmod 600 %s'", perm, path)
+	}
+
+	for _, key := range cfg.UnknownKeys() {
+		if field, ok := strings.CutPrefix(key, "defaults."); ok {
+			s.add(checkWarn, "unknown key '%s'%s", key, suggestKey(field, config.ContextKeys()))
+		} else if !strings.HasPrefix(key, "context.") {
+			s.add(checkWarn, "unknown key '%s'%s", key, suggestKey(key, []string{"context", "defaults", "models"}))
+		}
+	}
+	return cfg
+}
+
+func checkClaude() doctorSection {
+	s := doctorSection{Name: "claude"}
+	path, err := exec.LookPath("claude")
+	if err != nil {
+		s.add(checkWarn, "claude not found in PATH; run needs it, exec does not")
+		return s
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), claudeVersionTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, path, "--version").Output()
+	if err != nil {
+		s.add(checkWarn, "%s found, but 'claude --version' failed: %v", path, err)
+		return s
+	}
+	version, _, _ := strings.Cut(strings.TrimSpace(string(out)), "\n")
+	s.add(checkPass, "%s, version %s", path, version)
+	return s
+}
+
+func checkContext(cfg *config.Config, name string) doctorSection {
+	s := doctorSection{Name: name}
+	for _, key := range cfg.UnknownKeys() {
+		if field, ok := strings.CutPrefix(key, "context."+name+"."); ok {
+			s.add(checkWarn, "unknown key '%s'%s", field, suggestKey(field, config.ContextKeys()))
+		}
+	}
+
+	ctx, err := cfg.Resolve(name)
+	if err != nil {
+		s.add(checkFail, "%v", err)
+		return s
+	}
+	provider := ctx.ProviderName()
+	required, err := config.RequiredFields(provider)
+	if err != nil {
+		s.add(checkFail, "%v", err)
+	} else if ctx.Provider != "" {
+		s.add(checkPass, "provider %s", provider)
+	}
+	for _, key := range required {
+		value := ctx.Field(key)
+		switch {
+		case value == "":
+			s.add(checkFail, "missing %s", key)
+		case key == "auth_token":
+			checkSecret(cfg, &s, "auth_token", value)
+		case key == "base_url":
+			if err := runner.ValidateURL(value); err != nil {
+				s.add(checkFail, "%v", err)
+			} else {
+				s.add(checkPass, "base_url %s", value)
+			}
+		default:
+			s.add(checkPass, "%s %s", key, value)
+		}
+	}
+	// Values shared through [defaults] are expected to reach every provider
+	if err == nil && provider != config.ProviderAnthropic {
+		for _, key := range []string{"base_url", "auth_token"} {
+			if ctx.Field(key) != "" && ctx.Sources[key] != "defaults" {
+				s.add(checkWarn, "%s is ignored by provider %s", key, provider)
+			}
+		}
+	}
+	for _, key := range slices.Sorted(maps.Keys(ctx.Env)) {
+		if config.IsReference(ctx.Env[key]) {
+			checkSecret(cfg, &s, "env."+key, ctx.Env[key])
+		}
+	}
+
+	if s.Status == "" {
+		s.Status = checkPass
+	}
+	return s
+}
+
+// checkSecret resolves env: and file: references, which have no side
+// effects. Other references may run commands or prompt, so they are only
+// reported.
+func checkSecret(cfg *config.Config, s *doctorSection, key, value string) {
+	switch scheme := config.ReferenceScheme(value); {
+	case scheme == "":
+		s.add(checkPass, "%s set", key)
+	case config.ResolvesQuietly(value):
+		if _, err := cfg.ResolveSecret(value); err != nil {
+			s.add(checkFail, "%s: cannot resolve '%s': %v", key, value, err)
+		} else {
+			s.add(checkPass, "%s resolves from '%s'", key, value)
+		}
+	default:
+		s.add(checkPass, "%s uses a %s: reference, not resolved by doctor", key, scheme)
+	}
+}
+
+// suggestKey returns a hint naming the known key closest to an unknown one,
+// or "" when none is close.
+func suggestKey(key string, known []string) string {
+	best, bestDistance := "", 3
+	for _, candidate := range known {
+		if d := editDistance(key, candidate); d < bestDistance {
+			best, bestDistance = candidate, d
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	return fmt.Sprintf(" (did you mean '%s'?)", best)
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+func printDoctorReport(report doctorReport, w io.Writer) {
+	printSection := func(title string, s doctorSection) {
+		fmt.Fprintf(w, "%s: %s\n", title, s.Status)
+		for _, c := range s.Checks {
+			fmt.Fprintf(w, "  %s  %s\n", c.Status, c.Message)
+		}
+	}
+	printSection("Config "+report.Config.Name, report.Config)
+	printSection("Claude", report.Claude)
+	counts := map[string]int{}
+	for _, s := range report.Contexts {
+		printSection("Context '"+s.Name+"'", s)
+		counts[s.Status]++
+	}
+	fmt.Fprintf(w, "\n%s: %d passed, %d with warnings, %d failed\n",
+		countOf(len(report.Contexts), "context"), counts[checkPass], counts[checkWarn], counts[checkFail])
+}
+
+func countOf(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDoctorRun(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake claude needs sh")
+	}
+	bin := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(bin, "claude"), []byte("#!/bin/sh\necho '2.1.0 (Claude Code)'\n"), 0755))
+	t.Setenv("PATH", bin)
+	t.Setenv("WORK_TOKEN", "work-secret")
+	t.Setenv("MISSING_TOKEN", "")
+	os.Unsetenv("MISSING_TOKEN")
+
+	configPath := filepath.Join(t.TempDir(), "config.toml")
+	configTOML := `colour = "blue"
+
+[defaults]
+modle = "claude-sonnet-4-6"
+
+[context.work]
+base_url = "https://gateway.example.com"
+auth_token = "env:WORK_TOKEN"
+
+[context.vaulted]
+base_url = "https://api.anthropic.com"
+auth_token = "vault:personal"
+base_ur = "https://typo.example.com"
+
+[context.broken]
+base_url = "gateway.example.com"
+auth_token = "env:MISSING_TOKEN"
+
+[context.empty]
+description = "Nothing set yet"
+
+[context.aws]
+provider = "bedrock"
+aws_region = "us-east-1"
+auth_token = "env:WORK_TOKEN"
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(configTOML), 0644))
+	t.Setenv("CCCTX_CONFIG_PATH", configPath)
+
+	var out bytes.Buffer
+	assert.Equal(t, 1, doctorRun("json", &out))
+	var report doctorReport
+	require.NoError(t, json.Unmarshal(out.Bytes(), &report))
+
+	assert.False(t, report.OK)
+	assert.Equal(t, doctorSection{Name: configPath, Status: checkWarn, Checks: []doctorCheck{
+		{checkPass, "parsed 5 contexts"},
+		{checkWarn, "accessible by other users (mode 0644); run 'chmod 600 " + configPath + "'"},
+		{checkWarn, "unknown key 'colour'"},
+		{checkWarn, "unknown key 'defaults.modle' (did you mean 'model'?)"},
+	}}, report.Config)
+	assert.Equal(t, doctorSection{Name: "claude", Status: checkPass, Checks: []doctorCheck{
+		{checkPass, filepath.Join(bin, "claude") + ", version 2.1.0 (Claude Code)"},
+	}}, report.Claude)
+
+	require.Len(t, report.Contexts, 5)
+	assert.Equal(t, doctorSection{Name: "aws", Status: checkWarn, Checks: []doctorCheck{
+		{checkPass, "provider bedrock"},
+		{checkPass, "aws_region us-east-1"},
+		{checkWarn, "auth_token is ignored by provider bedrock"},
+	}}, report.Contexts[0])
+	assert.Equal(t, doctorSection{Name: "broken", Status: checkFail, Checks: []doctorCheck{
+		{checkFail, "invalid base_url: missing scheme (e.g., https://)"},
+		{checkFail, "auth_token: cannot resolve 'env:MISSING_TOKEN': environment variable 'MISSING_TOKEN' is not set or empty"},
+	}}, report.Contexts[1])
+	assert.Equal(t, doctorSection{Name: "empty", Status: checkFail, Checks: []doctorCheck{
+		{checkFail, "missing base_url"},
+		{checkFail, "missing auth_token"},
+	}}, report.Contexts[2])
+	assert.Equal(t, doctorSection{Name: "vaulted", Status: checkWarn, Checks: []doctorCheck{
+		{checkWarn, "unknown key 'base_ur' (did you mean 'base_url'?)"},
+		{checkPass, "base_url https://api.anthropic.com"},
+		{checkPass, "auth_token uses a vault: reference, not resolved by doctor"},
+	}}, report.Contexts[3])
+	assert.Equal(t, doctorSection{Name: "work", Status: checkPass, Checks: []doctorCheck{
+		{checkPass, "base_url https://gateway.example.com"},
+		{checkPass, "auth_token resolves from 'env:WORK_TOKEN'"},
+	}}, report.Contexts[4])
+
+	out.Reset()
+	require.NoError(t, os.Chmod(configPath, 0600))
+	require.NoError(t, os.WriteFile(configPath, []byte("[context.work]\nbase_url = \"https://gateway.example.com\"\nauth_token = \"literal\"\n"), 0600))
+	assert.Equal(t, 0, doctorRun("", &out))
+	assert.Equal(t, "Config "+configPath+": pass\n"+
+		"  pass  parsed 1 context\n"+
+		"Claude: pass\n"+
+		"  pass  "+filepath.Join(bin, "claude")+", version 2.1.0 (Claude Code)\n"+
+		"Context 'work': pass\n"+
+		"  pass  base_url https://gateway.example.com\n"+
+		"  pass  auth_token set\n"+
+		"\n1 context: 1 passed, 0 with warnings, 0 failed\n", out.String())
+}
+
+func TestDoctorRun_ParseError(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+	configPath := filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, os.WriteFile(configPath, []byte("[context.work]\nbase_url = \"https://gateway.example.com\"\nauth_token = \n"), 0600))
+	t.Setenv("CCCTX_CONFIG_PATH", configPath)
+
+	var out bytes.Buffer
+	assert.Equal(t, 1, doctorRun("json", &out))
+	var report doctorReport
+	require.NoError(t, json.Unmarshal(out.Bytes(), &report))
+	assert.Equal(t, []doctorCheck{{checkFail, "failed to read config file: line 3, column 14: toml: incomplete number"}}, report.Config.Checks)
+	assert.Equal(t, checkWarn, report.Claude.Status)
+	assert.Empty(t, report.Contexts)
+}
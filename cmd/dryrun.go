@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/dsdashun/ccctx/config"
+	"github.com/dsdashun/ccctx/internal/mask"
+	"github.com/dsdashun/ccctx/internal/runner"
+)
+
+// printDryRun describes what r would execute: the command line, working
+// directory and environment changes, with secrets masked.
+func printDryRun(r *runner.Runner, name string, filled []string, project *config.Project, stdout io.Writer) error {
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	quoted := make([]string, 0, len(r.Command()))
+	for _, arg := range r.Command() {
+		quoted = append(quoted, shellQuote(arg))
+	}
+	fmt.Fprintf(stdout, "Context: %s\n", name)
+	if project != nil {
+		fmt.Fprintf(stdout, "Project: %s\n", project.Path)
+	}
+	fmt.Fprintf(stdout, "Command: %s\n", strings.Join(quoted, " "))
+	fmt.Fprintf(stdout, "Working directory: %s\n", wd)
+
+	changes := r.EnvDiff()
+	if len(changes) == 0 {
+		fmt.Fprintln(stdout, "\nEnvironment: unchanged")
+		return nil
+	}
+	fmt.Fprintln(stdout, "\nEnvironment:")
+	w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	display := func(value string, secret bool) string {
+		if secret {
+			return mask.Secret(value)
+		}
+		return value
+	}
+	for _, c := range changes {
+		source := projectSource(c.Source, filled, project)
+		switch c.Kind {
+		case runner.EnvAdded:
+			fmt.Fprintf(w, "  + %s=%s\t%s\n", c.Name, display(c.New, c.Secret), source)
+		case runner.EnvOverridden:
+			fmt.Fprintf(w, "  ~ %s=%s (was %s)\t%s\n", c.Name, display(c.New, c.Secret), display(c.Old, c.Secret), source)
+		case runner.EnvRemoved:
+			fmt.Fprintf(w, "  - %s\n", c.Name)
+		}
+	}
+	return w.Flush()
+}
+
+// shellQuote quotes s for a POSIX shell when it contains anything but plain
+// word characters.
+func shellQuote(s string) string {
+	if s != "" && strings.IndexFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("@%_-+=:,./", r))
+	}) < 0 {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dsdashun/ccctx/internal/runner"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrintDryRun(t *testing.T) {
+	const token = "sk-ant-REDACTED"
+	configPath := filepath.Join(t.TempDir(), "config.toml")
+	configTOML := `[context.work]
+base_url = "https://api.example.com"
+auth_token = "env:CCCTX_TEST_DRYRUN_TOKEN"
+model = "work-model"
+unset = ["CCCTX_TEST_DRYRUN_GONE"]
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(configTOML), 0600))
+	t.Setenv("CCCTX_CONFIG_PATH", configPath)
+	t.Setenv("CCCTX_TEST_DRYRUN_TOKEN", token)
+	t.Setenv("CCCTX_TEST_DRYRUN_GONE", "1")
+	t.Setenv("ANTHROPIC_MODEL", "inherited-model")
+	for _, name := range []string{"ANTHROPIC_BASE_URL", "ANTHROPIC_AUTH_TOKEN", "ANTHROPIC_DEFAULT_OPUS_MODEL"} {
+		t.Setenv(name, "") // restores the variable after the test
+		os.Unsetenv(name)
+	}
+
+	r, err := runner.New(runner.Options{
+		ContextName: "work",
+		Target:      []string{"/usr/bin/claude", "--print", "it's done"},
+		OpusModel:   "flag-opus",
+	})
+	require.NoError(t, err)
+
+	var stdout bytes.Buffer
+	require.NoError(t, printDryRun(r, "work", nil, nil, &stdout))
+	out := stdout.String()
+
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	assert.Contains(t, out, "Context: work\n")
+	assert.Contains(t, out, `Command: /usr/bin/claude --print 'it'\''s done'`+"\n")
+	assert.Contains(t, out, "Working directory: "+wd+"\n")
+	assert.Regexp(t, `\+ ANTHROPIC_BASE_URL=https://api.example.com\s+context.work`, out)
+	assert.Regexp(t, `\+ ANTHROPIC_AUTH_TOKEN=sk-a\*\*\*\*wxyz\s+context.work`, out)
+	assert.Regexp(t, `~ ANTHROPIC_MODEL=work-model \(was inherited-model\)\s+context.work`, out)
+	assert.Regexp(t, `\+ ANTHROPIC_DEFAULT_OPUS_MODEL=flag-opus\s+--opus-model`, out)
+	assert.Contains(t, out, "  - CCCTX_TEST_DRYRUN_GONE\n")
+	assert.NotContains(t, out, token)
+}
+
+func TestRunRun_DryRun(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.toml")
+	configTOML := "[context.work]\nbase_url = \"https://api.example.com\"\nauth_token = \"test-token\"\n"
+	require.NoError(t, os.WriteFile(configPath, []byte(configTOML), 0600))
+	t.Setenv("CCCTX_CONFIG_PATH", configPath)
+
+	outputFile := filepath.Join(t.TempDir(), "mock_output")
+	t.Setenv("MOCK_OUTPUT_FILE", outputFile)
+	mockDir := t.TempDir()
+	writeModelMock(t, mockDir, "claude")
+	t.Setenv("PATH", mockDir)
+
+	assert.Equal(t, 0, runRun([]string{"work", "--dry-run"}))
+	assert.Equal(t, 0, execRun([]string{"work", "--dry-run", "--", "claude"}))
+	_, err := os.Stat(outputFile)
+	assert.True(t, os.IsNotExist(err), "dry run must not execute the target")
+}
+
+func TestRunRun_DryRunWithoutClaude(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.toml")
+	configTOML := "[context.work]\nbase_url = \"https://api.example.com\"\nauth_token = \"test-token\"\n"
+	require.NoError(t, os.WriteFile(configPath, []byte(configTOML), 0600))
+	t.Setenv("CCCTX_CONFIG_PATH", configPath)
+	t.Setenv("PATH", t.TempDir())
+
+	assert.Equal(t, 0, runRun([]string{"work", "--dry-run"}))
+	assert.Equal(t, 1, runRun([]string{"work"}))
+}
@@ -0,0 +1,235 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/dsdashun/ccctx/config"
+	"github.com/dsdashun/ccctx/internal/runner"
+	"github.com/spf13/cobra"
+)
+
+type envOptions struct {
+	flags runner.Flags
+	shell string
+	unset bool
+}
+
+var envOpts envOptions
+
+var EnvCmd = &cobra.Command{
+	Use:   "env [context]",
+	Short: "Print shell commands that activate a context",
+	Long: `Print the commands that give the current shell the environment run and exec would use, including unsetting stale ANTHROPIC_* variables. Activate a context with:
+
+  eval "$(ccctx env work)"                      # bash, zsh
+  ccctx env work --shell fish | source          # fish
+  ccctx env work --shell powershell | Invoke-Expression
+
+Without a context, uses the current one. With --unset, prints the commands that remove the context's variables again. The output contains the auth token in clear text.`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeContext,
+	Run: func(cmd *cobra.Command, args []string) {
+		os.Exit(envRun(args, envOpts, os.Stdout))
+	},
+}
+
+func init() {
+	flags := EnvCmd.Flags()
+	flags.StringVar(&envOpts.shell, "shell", "", "output format: bash, zsh, fish, powershell, dotenv or json (default: detected from $SHELL)")
+	flags.BoolVar(&envOpts.unset, "unset", false, "print commands that deactivate the context")
+	flags.StringVar(&envOpts.flags.Model, "model", "", "override the model")
+	flags.StringVar(&envOpts.flags.HaikuModel, "haiku-model", "", "override the Haiku-class model")
+	flags.StringVar(&envOpts.flags.SonnetModel, "sonnet-model", "", "override the Sonnet-class model")
+	flags.StringVar(&envOpts.flags.OpusModel, "opus-model", "", "override the Opus-class model")
+	registerModelCompletions(EnvCmd)
+	_ = EnvCmd.RegisterFlagCompletionFunc("shell", cobra.FixedCompletions([]cobra.Completion{"bash", "zsh", "fish", "powershell", "dotenv", "json"}, cobra.ShellCompDirectiveNoFileComp))
+}
+
+// shellFormat renders variable assignments and removals for one shell.
+type shellFormat struct {
+	set   func(name, value string) string
+	unset func(name string) string
+	// hint shows how to apply the output, printed as a trailing comment.
+	hint string
+}
+
+var shellFormats = map[string]shellFormat{
+	"bash": {
+		set:   func(name, value string) string { return "export " + name + "=" + shellQuote(value) },
+		unset: func(name string) string { return "unset " + name },
+		hint:  `eval "$(ccctx env%s)"`,
+	},
+	"zsh": {
+		set:   func(name, value string) string { return "export " + name + "=" + shellQuote(value) },
+		unset: func(name string) string { return "unset " + name },
+		hint:  `eval "$(ccctx env%s)"`,
+	},
+	"fish": {
+		set: func(name, value string) string {
+			return "set -gx " + name + " '" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
+		},
+		unset: func(name string) string { return "set -e " + name },
+		hint:  "ccctx env%s | source",
+	},
+	"powershell": {
+		set: func(name, value string) string {
+			return "$env:" + name + " = '" + strings.ReplaceAll(value, "'", "''") + "'"
+		},
+		unset: func(name string) string { return "Remove-Item Env:" + name + " -ErrorAction SilentlyContinue" },
+		hint:  "ccctx env%s | Invoke-Expression",
+	},
+	"dotenv": {
+		set: func(name, value string) string {
+			return name + `="` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "$", `\$`).Replace(value) + `"`
+		},
+		// dotenv files cannot remove variables; note them for the reader
+		unset: func(name string) string { return "# unset " + name },
+	},
+}
+
+// detectShell picks the output format from $SHELL.
+func detectShell() string {
+	switch name := strings.TrimSuffix(filepath.Base(os.Getenv("SHELL")), ".exe"); name {
+	case "zsh", "fish":
+		return name
+	case "pwsh", "powershell":
+		return "powershell"
+	}
+	if runtime.GOOS == "windows" && os.Getenv("SHELL") == "" {
+		return "powershell"
+	}
+	return "bash"
+}
+
+func envRun(args []string, opts envOptions, stdout io.Writer) int {
+	shell := opts.shell
+	if shell == "" {
+		shell = detectShell()
+	}
+	format, known := shellFormats[shell]
+	if !known && shell != "json" {
+		fmt.Fprintf(os.Stderr, "Error: unknown shell '%s': use bash, zsh, fish, powershell, dotenv or json\n", shell)
+		return 1
+	}
+
+	var name string
+	var project *config.Project
+	if len(args) == 1 {
+		name = args[0]
+	} else {
+		var err error
+		name, project, err = defaultContext()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		if name == "" && !opts.unset {
+			fmt.Fprintln(os.Stderr, "Error: no context given and no current context set")
+			return 1
+		}
+	}
+
+	set := map[string]string{}
+	var setOrder, unset []string
+	if opts.unset {
+		names, err := deactivatedVars(name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		unset = names
+	} else {
+		flags := opts.flags
+		applyProject(&flags, project)
+		changes, err := runner.EnvChanges(runner.Options{
+			ContextName: name,
+			Model:       flags.Model,
+			HaikuModel:  flags.HaikuModel,
+			SonnetModel: flags.SonnetModel,
+			OpusModel:   flags.OpusModel,
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		for _, c := range changes {
+			if c.Kind == runner.EnvRemoved {
+				unset = append(unset, c.Name)
+			} else {
+				set[c.Name] = c.New
+				setOrder = append(setOrder, c.Name)
+			}
+		}
+	}
+
+	if shell == "json" {
+		data, err := json.MarshalIndent(struct {
+			Set   map[string]string `json:"set"`
+			Unset []string          `json:"unset"`
+		}{set, append([]string{}, unset...)}, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		fmt.Fprintln(stdout, string(data))
+		return 0
+	}
+
+	for _, n := range unset {
+		fmt.Fprintln(stdout, format.unset(n))
+	}
+	for _, n := range setOrder {
+		fmt.Fprintln(stdout, format.set(n, set[n]))
+	}
+	if format.hint != "" {
+		var hintArgs string
+		if len(args) == 1 {
+			hintArgs += " " + args[0]
+		}
+		if opts.shell != "" {
+			hintArgs += " --shell " + opts.shell
+		}
+		if opts.unset {
+			hintArgs += " --unset"
+		}
+		fmt.Fprintf(stdout, "# Run this command to configure your shell:\n# "+format.hint+"\n", hintArgs)
+	}
+	return 0
+}
+
+// deactivatedVars returns the variables 'ccctx env --unset' removes: every
+// ANTHROPIC_* variable currently set, plus the variables the named context
+// sets when there is one, such as its provider's and its extra env.
+func deactivatedVars(name string) ([]string, error) {
+	var names []string
+	for _, e := range os.Environ() {
+		n, _, _ := strings.Cut(e, "=")
+		if strings.HasPrefix(n, "ANTHROPIC_") {
+			names = append(names, n)
+		}
+	}
+	if name != "" {
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			return nil, err
+		}
+		ctx, err := cfg.Resolve(name)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range runner.InjectedEnv(ctx, runner.Options{}) {
+			if !slices.Contains(names, v.Name) {
+				names = append(names, v.Name)
+			}
+		}
+	}
+	slices.Sort(names)
+	return names, nil
+}
@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dsdashun/ccctx/internal/runner"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupEnvTest(t *testing.T) {
+	t.Helper()
+	configPath := filepath.Join(t.TempDir(), "config.toml")
+	configTOML := `[context.work]
+base_url = "https://api.example.com"
+auth_token = "tok'en \"$HOME\""
+unset = ["CCCTX_TEST_ENV_GONE"]
+
+[context.work.env]
+CCCTX_TEST_ENV_EXTRA = "line1\nline2"
+
+[context.aws]
+provider = "bedrock"
+aws_region = "us-east-1"
+aws_profile = "claude"
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(configTOML), 0600))
+	t.Setenv("CCCTX_CONFIG_PATH", configPath)
+	t.Setenv("CCCTX_TEST_ENV_GONE", "1")
+	t.Setenv("ANTHROPIC_API_KEY", "stale-key")
+	for _, name := range []string{"ANTHROPIC_BASE_URL", "ANTHROPIC_AUTH_TOKEN", "ANTHROPIC_MODEL"} {
+		t.Setenv(name, "") // restores the variable after the test
+		os.Unsetenv(name)
+	}
+}
+
+func TestEnvRun_POSIXRoundTrip(t *testing.T) {
+	setupEnvTest(t)
+
+	var stdout bytes.Buffer
+	require.Equal(t, 0, envRun([]string{"work"}, envOptions{shell: "bash"}, &stdout))
+
+	script := stdout.String() + `
+printf '%s|' "${ANTHROPIC_API_KEY-unset}" "${CCCTX_TEST_ENV_GONE-unset}" "$ANTHROPIC_BASE_URL" "$ANTHROPIC_AUTH_TOKEN" "$CCCTX_TEST_ENV_EXTRA"`
+	out, err := exec.Command("sh", "-c", script).Output()
+	require.NoError(t, err)
+	assert.Equal(t, "unset|unset|https://api.example.com|tok'en \"$HOME\"|line1\nline2|", string(out))
+}
+
+func TestEnvRun(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		opts     envOptions
+		wantCode int
+		want     []string
+	}{
+		{
+			name:     "fish",
+			args:     []string{"work"},
+			opts:     envOptions{shell: "fish"},
+			wantCode: 0,
+			want: []string{
+				"set -e ANTHROPIC_API_KEY\n",
+				"set -gx ANTHROPIC_AUTH_TOKEN 'tok\\'en \"$HOME\"'\n",
+				"# ccctx env work --shell fish | source\n",
+			},
+		},
+		{
+			name:     "powershell",
+			args:     []string{"work"},
+			opts:     envOptions{shell: "powershell"},
+			wantCode: 0,
+			want: []string{
+				"Remove-Item Env:CCCTX_TEST_ENV_GONE -ErrorAction SilentlyContinue\n",
+				"$env:ANTHROPIC_AUTH_TOKEN = 'tok''en \"$HOME\"'\n",
+			},
+		},
+		{
+			name:     "dotenv",
+			args:     []string{"work"},
+			opts:     envOptions{shell: "dotenv"},
+			wantCode: 0,
+			want: []string{
+				"ANTHROPIC_AUTH_TOKEN=\"tok'en \\\"\\$HOME\\\"\"\n",
+				"CCCTX_TEST_ENV_EXTRA=\"line1\\nline2\"\n",
+			},
+		},
+		{
+			name:     "model override",
+			args:     []string{"work"},
+			opts:     envOptions{shell: "bash", flags: runner.Flags{Model: "flag-model"}},
+			wantCode: 0,
+			want:     []string{"export ANTHROPIC_MODEL=flag-model\n"},
+		},
+		{
+			name:     "unset",
+			args:     []string{"work"},
+			opts:     envOptions{shell: "bash", unset: true},
+			wantCode: 0,
+			want: []string{
+				"unset ANTHROPIC_API_KEY\n",
+				"unset CCCTX_TEST_ENV_EXTRA\n",
+				"# eval \"$(ccctx env work --shell bash --unset)\"\n",
+			},
+		},
+		{
+			name:     "unset provider variables",
+			args:     []string{"aws"},
+			opts:     envOptions{shell: "bash", unset: true},
+			wantCode: 0,
+			want: []string{
+				"unset AWS_PROFILE\n",
+				"unset AWS_REGION\n",
+				"unset CLAUDE_CODE_USE_BEDROCK\n",
+			},
+		},
+		{
+			name:     "unknown shell",
+			args:     []string{"work"},
+			opts:     envOptions{shell: "tcsh"},
+			wantCode: 1,
+		},
+		{
+			name:     "unknown context",
+			args:     []string{"missing"},
+			opts:     envOptions{shell: "bash"},
+			wantCode: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupEnvTest(t)
+
+			var stdout bytes.Buffer
+			code := envRun(tt.args, tt.opts, &stdout)
+			assert.Equal(t, tt.wantCode, code)
+			for _, want := range tt.want {
+				assert.Contains(t, stdout.String(), want)
+			}
+		})
+	}
+}
+
+func TestEnvRun_JSON(t *testing.T) {
+	setupEnvTest(t)
+
+	var stdout bytes.Buffer
+	require.Equal(t, 0, envRun([]string{"work"}, envOptions{shell: "json"}, &stdout))
+
+	var got struct {
+		Set   map[string]string `json:"set"`
+		Unset []string          `json:"unset"`
+	}
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &got))
+	assert.Equal(t, map[string]string{
+		"ANTHROPIC_BASE_URL":   "https://api.example.com",
+		"ANTHROPIC_AUTH_TOKEN": `tok'en "$HOME"`,
+		"CCCTX_TEST_ENV_EXTRA": "line1\nline2",
+	}, got.Set)
+	assert.Subset(t, got.Unset, []string{"ANTHROPIC_API_KEY", "CCCTX_TEST_ENV_GONE"})
+	assert.False(t, strings.Contains(stdout.String(), "# "), "json output must not carry hints")
+}
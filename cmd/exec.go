@@ -4,8 +4,10 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/dsdashun/ccctx/config"
+	"github.com/dsdashun/ccctx/internal/history"
 	"github.com/dsdashun/ccctx/internal/runner"
 	"github.com/dsdashun/ccctx/internal/ui"
 	"github.com/spf13/cobra"
@@ -14,9 +16,10 @@ import (
 var ExecCmd = &cobra.Command{
 	Use:                "exec [context] [-- command...]",
 	Short:              "Execute a command or launch a shell with a context",
-	Long:               "Execute a command or launch a shell with the specified context. If no command is given, launches $SHELL. If no context is given, opens the interactive selector.",
+	Long:               "Execute a command or launch a shell with the specified context. If no command is given, launches $SHELL. If no context is given, uses the current context set by 'ccctx use', or opens the interactive selector if none is set or --select is given. With --dry-run, prints the command and environment changes instead of executing it. With --replace, ccctx replaces itself with the command instead of waiting for it, which is not supported on Windows.",
 	Args:               cobra.ArbitraryArgs,
 	DisableFlagParsing: true,
+	ValidArgsFunction:  completeRunArgs,
 	Run: func(cmd *cobra.Command, args []string) {
 		if runner.WantsHelp(args) {
 			if err := cmd.Help(); err != nil {
@@ -29,7 +32,7 @@ var ExecCmd = &cobra.Command{
 }
 
 func execRun(args []string) int {
-	model, haikuModel, sonnetModel, opusModel, args, err := runner.ExtractFlags(args)
+	flags, args, err := runner.ExtractFlags(args)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		return 1
@@ -41,17 +44,10 @@ func execRun(args []string) int {
 		return 1
 	}
 
+	var project *config.Project
+	var filled []string
 	if useTUI {
-		contexts, err := config.ListContexts()
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-			return 1
-		}
-		if len(contexts) == 0 {
-			fmt.Fprintf(os.Stderr, "Error: no contexts found\n")
-			return 1
-		}
-		provider, err = ui.RunContextSelector(contexts)
+		provider, project, err = chooseContext(flags.Select)
 		if err != nil {
 			if errors.Is(err, ui.ErrCancelled) {
 				fmt.Fprintln(os.Stderr, "Operation cancelled.")
@@ -60,6 +56,7 @@ func execRun(args []string) int {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			return 1
 		}
+		filled = applyProject(&flags, project)
 	}
 
 	if len(targetArgs) == 0 {
@@ -71,17 +68,31 @@ func execRun(args []string) int {
 		targetArgs = []string{shell}
 	}
 
-	opts := runner.Options{ContextName: provider, Target: targetArgs, Model: model, HaikuModel: haikuModel, SonnetModel: sonnetModel, OpusModel: opusModel}
+	opts := runner.Options{ContextName: provider, Target: targetArgs, Model: flags.Model, HaikuModel: flags.HaikuModel, SonnetModel: flags.SonnetModel, OpusModel: flags.OpusModel, ForwardSignals: true}
 	r, err := runner.New(opts)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		return 1
 	}
 
+	if flags.DryRun {
+		if err := printDryRun(r, provider, filled, project, os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	if flags.Replace {
+		return replaceRun(r, provider)
+	}
+
+	start := time.Now()
 	exitCode, err := r.Run()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		return 1
+		exitCode = 1
 	}
+	recordRun(history.Entry{Context: provider, Start: start, Command: r.Command(), ExitCode: exitCode, Duration: time.Since(start)})
 	return exitCode
 }
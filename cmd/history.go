@@ -0,0 +1,187 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dsdashun/ccctx/config"
+	"github.com/dsdashun/ccctx/internal/history"
+	"github.com/dsdashun/ccctx/internal/runner"
+	"github.com/spf13/cobra"
+)
+
+type historyOptions struct {
+	limit  int
+	since  time.Duration
+	failed bool
+	output string
+}
+
+var historyOpts historyOptions
+
+var HistoryCmd = &cobra.Command{
+	Use:   "history [context]",
+	Short: "List past runs",
+	Long: `List the programs run and exec launched, oldest first, with their context, exit code and duration. Their arguments are not recorded. With a context, lists only its runs.
+
+The history is kept in history.jsonl next to the config file. $CCCTX_HISTORY_LIMIT sets how many runs it keeps (default ` + strconv.Itoa(history.DefaultLimit) + `); 0 stops recording.`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeContext,
+	Run: func(cmd *cobra.Command, args []string) {
+		os.Exit(historyRun(args, historyOpts, os.Stdout))
+	},
+}
+
+func init() {
+	flags := HistoryCmd.Flags()
+	flags.IntVarP(&historyOpts.limit, "limit", "n", 20, "show at most this many of the latest runs (0 for all)")
+	flags.DurationVar(&historyOpts.since, "since", 0, "only show runs started within this duration, e.g. 24h")
+	flags.BoolVar(&historyOpts.failed, "failed", false, "only show runs that exited with a non-zero code")
+	flags.StringVarP(&historyOpts.output, "output", "o", "", "output format: json")
+	_ = HistoryCmd.RegisterFlagCompletionFunc("output", cobra.FixedCompletions([]cobra.Completion{"json"}, cobra.ShellCompDirectiveNoFileComp))
+}
+
+// historyRecord is a history entry in history's JSON output.
+type historyRecord struct {
+	Context  string    `json:"context"`
+	Start    time.Time `json:"start"`
+	Command  []string  `json:"command"`
+	ExitCode int       `json:"exit_code"`
+	Duration string    `json:"duration"`
+	Replaced bool      `json:"replaced,omitempty"`
+}
+
+func historyRun(args []string, opts historyOptions, stdout io.Writer) int {
+	if opts.output != "" && opts.output != "json" {
+		fmt.Fprintf(os.Stderr, "Error: unknown output format '%s': use json\n", opts.output)
+		return 1
+	}
+
+	path, err := config.GetHistoryPath()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	entries, err := history.Load(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	var shown []history.Entry
+	for _, e := range entries {
+		switch {
+		case len(args) == 1 && e.Context != args[0]:
+		case opts.since > 0 && time.Since(e.Start) > opts.since:
+		case opts.failed && (e.ExitCode == 0 || e.Replaced):
+		default:
+			shown = append(shown, e)
+		}
+	}
+	if opts.limit > 0 && len(shown) > opts.limit {
+		shown = shown[len(shown)-opts.limit:]
+	}
+
+	if opts.output == "json" {
+		records := make([]historyRecord, 0, len(shown))
+		for _, e := range shown {
+			records = append(records, historyRecord{e.Context, e.Start, e.Command, e.ExitCode, e.Duration.String(), e.Replaced})
+		}
+		data, err := json.MarshalIndent(records, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		fmt.Fprintln(stdout, string(data))
+		return 0
+	}
+
+	if len(shown) == 0 {
+		fmt.Fprintln(stdout, "No runs found.")
+		return 0
+	}
+	w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TIME\tCONTEXT\tEXIT\tDURATION\tCOMMAND")
+	for _, e := range shown {
+		exit, duration := strconv.Itoa(e.ExitCode), roundDuration(e.Duration)
+		if e.Replaced {
+			exit, duration = "-", "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", e.Start.Local().Format("2006-01-02 15:04:05"), e.Context, exit, duration, displayCommand(e.Command))
+	}
+	w.Flush()
+	return 0
+}
+
+// recordRun appends a launch to the history, dropping the arguments of its
+// command. Failing to record must not change the outcome of the run, so
+// errors are only reported.
+func recordRun(e history.Entry) {
+	e.Start = e.Start.UTC().Truncate(time.Second)
+	e.Command = e.Command[:min(len(e.Command), 1)]
+	path, err := config.GetHistoryPath()
+	if err == nil {
+		err = history.Append(path, e, history.Limit())
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to record run in history: %v\n", err)
+	}
+}
+
+// replaceRun replaces ccctx with the target of r, recording the launch just
+// before the exec since nothing runs after it succeeds. When the target
+// cannot be started, the failure is recorded instead.
+func replaceRun(r *runner.Runner, provider string) int {
+	start := time.Now()
+	recorded := false
+	err := r.Replace(func() {
+		recordRun(history.Entry{Context: provider, Start: start, Command: r.Command(), Replaced: true})
+		recorded = true
+	})
+	fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	if !recorded {
+		recordRun(history.Entry{Context: provider, Start: start, Command: r.Command(), ExitCode: 1})
+	}
+	return 1
+}
+
+// lastUsed returns when run or exec last launched each context, or nil when
+// the history cannot be read.
+func lastUsed() map[string]time.Time {
+	path, err := config.GetHistoryPath()
+	if err != nil {
+		return nil
+	}
+	entries, err := history.Load(path)
+	if err != nil {
+		return nil
+	}
+	return history.LastUsed(entries)
+}
+
+// displayCommand shell-quotes a recorded command, shortening the program to
+// its base name.
+func displayCommand(command []string) string {
+	quoted := make([]string, len(command))
+	for i, arg := range command {
+		if i == 0 {
+			arg = filepath.Base(arg)
+		}
+		quoted[i] = shellQuote(arg)
+	}
+	return strings.Join(quoted, " ")
+}
+
+func roundDuration(d time.Duration) string {
+	if d < time.Second {
+		return d.Round(time.Millisecond).String()
+	}
+	return d.Round(time.Second).String()
+}
@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dsdashun/ccctx/internal/history"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistoryRun(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		opts     historyOptions
+		wantCode int
+		want     []string
+	}{
+		{
+			name:     "all runs oldest first",
+			wantCode: 0,
+			want:     []string{"work", "personal", "work"},
+		},
+		{
+			name:     "filtered by context",
+			args:     []string{"personal"},
+			wantCode: 0,
+			want:     []string{"personal"},
+		},
+		{
+			name:     "limited to the latest runs",
+			opts:     historyOptions{limit: 2},
+			wantCode: 0,
+			want:     []string{"personal", "work"},
+		},
+		{
+			name:     "failed runs only",
+			opts:     historyOptions{failed: true},
+			wantCode: 0,
+			want:     []string{"personal"},
+		},
+		{
+			name:     "recent runs only",
+			opts:     historyOptions{since: 2 * time.Hour},
+			wantCode: 0,
+			want:     []string{"work"},
+		},
+		{
+			name:     "no matches",
+			args:     []string{"missing"},
+			wantCode: 0,
+		},
+		{
+			name:     "unknown output",
+			opts:     historyOptions{output: "xml"},
+			wantCode: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			t.Setenv("CCCTX_CONFIG_PATH", filepath.Join(tmpDir, "config.toml"))
+			path := filepath.Join(tmpDir, "history.jsonl")
+			now := time.Now()
+			for _, e := range []history.Entry{
+				{Context: "work", Start: now.Add(-48 * time.Hour), Command: []string{"/usr/bin/claude"}, Duration: 90 * time.Second},
+				{Context: "personal", Start: now.Add(-24 * time.Hour), Command: []string{"/bin/sh"}, ExitCode: 3, Duration: 1500 * time.Millisecond},
+				{Context: "work", Start: now.Add(-time.Hour), Command: []string{"/usr/bin/claude"}, Duration: 250 * time.Millisecond},
+			} {
+				require.NoError(t, history.Append(path, e, 10))
+			}
+
+			var stdout bytes.Buffer
+			code := historyRun(tt.args, tt.opts, &stdout)
+			assert.Equal(t, tt.wantCode, code)
+			if tt.wantCode != 0 {
+				return
+			}
+			if len(tt.want) == 0 {
+				assert.Equal(t, "No runs found.\n", stdout.String())
+				return
+			}
+			lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+			assert.Regexp(t, `^TIME\s+CONTEXT\s+EXIT\s+DURATION\s+COMMAND$`, lines[0])
+			var contexts []string
+			for _, line := range lines[1:] {
+				// date, time, context, ...
+				contexts = append(contexts, strings.Fields(line)[2])
+			}
+			assert.Equal(t, tt.want, contexts)
+		})
+	}
+}
+
+func TestHistoryRun_Output(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("CCCTX_CONFIG_PATH", filepath.Join(tmpDir, "config.toml"))
+	start := time.Date(2026, 10, 18, 9, 30, 0, 0, time.UTC)
+	require.NoError(t, history.Append(filepath.Join(tmpDir, "history.jsonl"), history.Entry{
+		Context:  "work",
+		Start:    start,
+		Command:  []string{"/bin/sh"},
+		ExitCode: 3,
+		Duration: 1500 * time.Millisecond,
+	}, 10))
+
+	var stdout bytes.Buffer
+	require.Equal(t, 0, historyRun(nil, historyOptions{}, &stdout))
+	assert.Contains(t, stdout.String(), start.Local().Format("2006-01-02 15:04:05"))
+	assert.Regexp(t, `work\s+3\s+2s\s+sh\n$`, stdout.String())
+
+	stdout.Reset()
+	require.Equal(t, 0, historyRun(nil, historyOptions{output: "json"}, &stdout))
+	var records []historyRecord
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &records))
+	assert.Equal(t, []historyRecord{{
+		Context:  "work",
+		Start:    start,
+		Command:  []string{"/bin/sh"},
+		ExitCode: 3,
+		Duration: "1.5s",
+	}}, records)
+
+	require.NoError(t, history.Append(filepath.Join(tmpDir, "history.jsonl"), history.Entry{
+		Context:  "work",
+		Start:    start,
+		Command:  []string{"/usr/bin/claude"},
+		Replaced: true,
+	}, 10))
+	stdout.Reset()
+	require.Equal(t, 0, historyRun(nil, historyOptions{}, &stdout))
+	assert.Regexp(t, `work\s+-\s+-\s+claude\n$`, stdout.String())
+	stdout.Reset()
+	require.Equal(t, 0, historyRun(nil, historyOptions{failed: true}, &stdout))
+	assert.Regexp(t, `work\s+3\s+2s\s+sh\n$`, stdout.String(), "replaced runs have no exit code to fail with")
+
+	os.Remove(filepath.Join(tmpDir, "history.jsonl"))
+	stdout.Reset()
+	require.Equal(t, 0, historyRun(nil, historyOptions{output: "json"}, &stdout))
+	assert.Equal(t, "[]\n", stdout.String())
+}
+
+func TestExecRun_ReplaceFailureRecorded(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.toml")
+	configTOML := "[context.work]\nbase_url = \"https://api.example.com\"\nauth_token = \"test-token\"\n"
+	require.NoError(t, os.WriteFile(configPath, []byte(configTOML), 0600))
+	t.Setenv("CCCTX_CONFIG_PATH", configPath)
+	t.Setenv("PATH", t.TempDir())
+
+	assert.Equal(t, 1, execRun([]string{"work", "--replace", "--", "missing-command", "-p", "a private prompt"}))
+
+	entries, err := history.Load(filepath.Join(tmpDir, "history.jsonl"))
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, []string{"missing-command"}, entries[0].Command)
+	assert.Equal(t, 1, entries[0].ExitCode)
+	assert.False(t, entries[0].Replaced, "a failed exec did not replace ccctx")
+}
@@ -1,32 +1,207 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"strings"
+	"text/tabwriter"
 
 	"github.com/dsdashun/ccctx/config"
+	"github.com/dsdashun/ccctx/internal/mask"
 	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
 )
 
+var listOutput string
+
 var ListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List available contexts",
-	Long:  "List all available contexts from the configuration file",
+	Long:  "List all available contexts from the configuration file. With --output, prints each context's details as json, yaml or a table, or just the names, for scripts. Auth tokens given literally or as env: or file: references are resolved to report whether they work, but are always masked; cmd: and vault: references, which may run commands or prompt, are reported as unverified.",
+	Args:  cobra.NoArgs,
 	Run: func(cmd *cobra.Command, args []string) {
-		contexts, err := config.ListContexts()
+		os.Exit(listRun(listOutput, os.Stdout))
+	},
+}
+
+func init() {
+	ListCmd.Flags().StringVarP(&listOutput, "output", "o", "", "output format: json, yaml, table or names")
+	_ = ListCmd.RegisterFlagCompletionFunc("output", cobra.FixedCompletions([]cobra.Completion{"json", "yaml", "table", "names"}, cobra.ShellCompDirectiveNoFileComp))
+}
+
+// contextInfo describes a context in list's machine-readable output.
+type contextInfo struct {
+	Name          string   `json:"name" yaml:"name"`
+	Extends       string   `json:"extends,omitempty" yaml:"extends,omitempty"`
+	Description   string   `json:"description,omitempty" yaml:"description,omitempty"`
+	Tags          []string `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Provider      string   `json:"provider,omitempty" yaml:"provider,omitempty"`
+	BaseURL       string   `json:"base_url,omitempty" yaml:"base_url,omitempty"`
+	Model         string   `json:"model,omitempty" yaml:"model,omitempty"`
+	HaikuModel    string   `json:"haiku_model,omitempty" yaml:"haiku_model,omitempty"`
+	SonnetModel   string   `json:"sonnet_model,omitempty" yaml:"sonnet_model,omitempty"`
+	OpusModel     string   `json:"opus_model,omitempty" yaml:"opus_model,omitempty"`
+	AuthToken     string   `json:"auth_token,omitempty" yaml:"auth_token,omitempty"`
+	AuthTokenRef  string   `json:"auth_token_ref,omitempty" yaml:"auth_token_ref,omitempty"`
+	TokenResolves bool     `json:"token_resolves" yaml:"token_resolves"`
+	TokenError    string   `json:"token_error,omitempty" yaml:"token_error,omitempty"`
+	// TokenUnverified marks tokens whose reference was not resolved because
+	// resolving it could run a command or prompt.
+	TokenUnverified bool `json:"token_unverified,omitempty" yaml:"token_unverified,omitempty"`
+	// Error is set when the context itself is invalid, e.g. an extends cycle.
+	Error string `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+func listRun(output string, stdout io.Writer) int {
+	switch output {
+	case "", "json", "yaml", "table", "names":
+	default:
+		fmt.Fprintf(os.Stderr, "Error: unknown output format '%s': use json, yaml, table or names\n", output)
+		return 1
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	contexts, err := config.ListContexts()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	switch output {
+	case "names":
+		for _, name := range contexts {
+			fmt.Fprintln(stdout, name)
+		}
+		return 0
+	case "":
+		printContexts(cfg, contexts, stdout)
+		return 0
+	}
+
+	infos := make([]contextInfo, 0, len(contexts))
+	for _, name := range contexts {
+		infos = append(infos, describeContext(cfg, name))
+	}
+
+	switch output {
+	case "json":
+		data, err := json.MarshalIndent(infos, "", "  ")
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-			os.Exit(1)
+			return 1
 		}
-
-		if len(contexts) == 0 {
-			fmt.Println("No contexts found.")
-			return
+		fmt.Fprintln(stdout, string(data))
+	case "yaml":
+		data, err := yaml.Marshal(infos)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		fmt.Fprint(stdout, string(data))
+	case "table":
+		w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tBASE URL\tMODEL\tTAGS\tTOKEN")
+		for _, info := range infos {
+			endpoint, token := dash(info.BaseURL), info.AuthToken
+			switch {
+			case info.Error != "":
+				token = "invalid"
+			case info.Provider != "" && info.Provider != config.ProviderAnthropic:
+				endpoint, token = info.Provider, "-"
+			case info.TokenUnverified:
+				token = "unverified"
+			case !info.TokenResolves:
+				token = "unresolved"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", info.Name, endpoint, dash(info.Model), dash(strings.Join(info.Tags, ",")), token)
 		}
+		w.Flush()
+	}
+	return 0
+}
+
+func printContexts(cfg *config.Config, contexts []string, stdout io.Writer) {
+	if len(contexts) == 0 {
+		fmt.Fprintln(stdout, "No contexts found.")
+		return
+	}
 
-		fmt.Println("Available contexts:")
-		for _, ctx := range contexts {
-			fmt.Printf("  %s\n", ctx)
+	fmt.Fprintln(stdout, "Available contexts:")
+	for _, name := range contexts {
+		ctx, err := cfg.Resolve(name)
+		switch {
+		case err != nil:
+			fmt.Fprintf(stdout, "  %s (invalid: %v)\n", name, err)
+		case ctx.Extends != "":
+			fmt.Fprintf(stdout, "  %s (extends %s)\n", name, ctx.Extends)
+		default:
+			fmt.Fprintf(stdout, "  %s\n", name)
 		}
-	},
+	}
+}
+
+// describeContext resolves a context for machine-readable output. The auth
+// token of anthropic contexts is resolved to check that it works, unless that
+// could run a command or prompt, and only ever reported masked.
+func describeContext(cfg *config.Config, name string) contextInfo {
+	info := contextInfo{Name: name}
+	ctx, err := cfg.Resolve(name)
+	if err != nil {
+		info.Error = err.Error()
+		return info
+	}
+
+	info.Extends = ctx.Extends
+	info.Description = ctx.Description
+	info.Tags = ctx.Tags
+	info.Provider = ctx.Provider
+	info.BaseURL = ctx.BaseURL
+	expand := func(value string) string {
+		model, _ := ctx.ExpandModel(value)
+		return model
+	}
+	info.Model = expand(ctx.Model)
+	info.HaikuModel = expand(ctx.HaikuModel)
+	if info.HaikuModel == "" {
+		info.HaikuModel = expand(ctx.SmallFastModel)
+	}
+	info.SonnetModel = expand(ctx.SonnetModel)
+	info.OpusModel = expand(ctx.OpusModel)
+
+	if ctx.ProviderName() != config.ProviderAnthropic {
+		return info
+	}
+	if ctx.AuthToken == "" {
+		info.TokenError = "auth_token is not set"
+		return info
+	}
+	if config.IsReference(ctx.AuthToken) {
+		info.AuthTokenRef = ctx.AuthToken
+	}
+	if !config.ResolvesQuietly(ctx.AuthToken) {
+		info.TokenUnverified = true
+		return info
+	}
+	token, err := cfg.ResolveSecret(ctx.AuthToken)
+	if err != nil {
+		info.TokenError = err.Error()
+		return info
+	}
+	info.TokenResolves = true
+	info.AuthToken = mask.Secret(token)
+	return info
+}
+
+func dash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
 }
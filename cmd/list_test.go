@@ -0,0 +1,165 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+const listConfigTOML = `[models]
+opus = "claude-opus-4-7"
+
+[context.zeta]
+base_url = "https://zeta.example.com"
+auth_token = "env:CCCTX_TEST_LIST_TOKEN"
+model = "zeta-model"
+small_fast_model = "zeta-fast"
+description = "Zeta gateway"
+tags = ["prod", "eu"]
+
+[context.alpha]
+base_url = "https://alpha.example.com"
+auth_token = "env:CCCTX_TEST_LIST_MISSING"
+
+[context.mid]
+extends = "zeta"
+opus_model = "opus"
+
+[context.scripted]
+base_url = "https://scripted.example.com"
+auth_token = "cmd:echo from-command"
+
+[context.loop]
+extends = "loop"
+`
+
+func TestListRun(t *testing.T) {
+	const token = "sk-ant-REDACTED"
+
+	wantInfos := []contextInfo{
+		{
+			Name:         "alpha",
+			BaseURL:      "https://alpha.example.com",
+			AuthTokenRef: "env:CCCTX_TEST_LIST_MISSING",
+			TokenError:   "environment variable 'CCCTX_TEST_LIST_MISSING' is not set or empty",
+		},
+		{
+			Name:  "loop",
+			Error: "context inheritance cycle: loop -> loop",
+		},
+		{
+			Name:          "mid",
+			Extends:       "zeta",
+			BaseURL:       "https://zeta.example.com",
+			Model:         "zeta-model",
+			HaikuModel:    "zeta-fast",
+			OpusModel:     "claude-opus-4-7",
+			AuthToken:     "sk-a****wxyz",
+			AuthTokenRef:  "env:CCCTX_TEST_LIST_TOKEN",
+			TokenResolves: true,
+		},
+		{
+			Name:            "scripted",
+			BaseURL:         "https://scripted.example.com",
+			AuthTokenRef:    "cmd:echo from-command",
+			TokenUnverified: true,
+		},
+		{
+			Name:          "zeta",
+			Description:   "Zeta gateway",
+			Tags:          []string{"prod", "eu"},
+			BaseURL:       "https://zeta.example.com",
+			Model:         "zeta-model",
+			HaikuModel:    "zeta-fast",
+			AuthToken:     "sk-a****wxyz",
+			AuthTokenRef:  "env:CCCTX_TEST_LIST_TOKEN",
+			TokenResolves: true,
+		},
+	}
+
+	tests := []struct {
+		name     string
+		output   string
+		wantCode int
+		check    func(t *testing.T, out string)
+	}{
+		{
+			name:     "default output is sorted",
+			output:   "",
+			wantCode: 0,
+			check: func(t *testing.T, out string) {
+				assert.Equal(t, "Available contexts:\n  alpha\n  loop (invalid: context inheritance cycle: loop -> loop)\n  mid (extends zeta)\n  scripted\n  zeta\n", out)
+			},
+		},
+		{
+			name:     "names",
+			output:   "names",
+			wantCode: 0,
+			check: func(t *testing.T, out string) {
+				assert.Equal(t, "alpha\nloop\nmid\nscripted\nzeta\n", out)
+			},
+		},
+		{
+			name:     "json",
+			output:   "json",
+			wantCode: 0,
+			check: func(t *testing.T, out string) {
+				var got []contextInfo
+				require.NoError(t, json.Unmarshal([]byte(out), &got))
+				assert.Equal(t, wantInfos, got)
+			},
+		},
+		{
+			name:     "yaml",
+			output:   "yaml",
+			wantCode: 0,
+			check: func(t *testing.T, out string) {
+				var got []contextInfo
+				require.NoError(t, yaml.Unmarshal([]byte(out), &got))
+				assert.Equal(t, wantInfos, got)
+			},
+		},
+		{
+			name:     "table",
+			output:   "table",
+			wantCode: 0,
+			check: func(t *testing.T, out string) {
+				lines := bytes.Split(bytes.TrimSpace([]byte(out)), []byte("\n"))
+				require.Len(t, lines, 6)
+				assert.Regexp(t, `^NAME\s+BASE URL\s+MODEL\s+TAGS\s+TOKEN$`, string(lines[0]))
+				assert.Regexp(t, `^alpha\s+https://alpha.example.com\s+-\s+-\s+unresolved$`, string(lines[1]))
+				assert.Regexp(t, `^loop\s+-\s+-\s+-\s+invalid$`, string(lines[2]))
+				assert.Regexp(t, `^scripted\s+https://scripted.example.com\s+-\s+-\s+unverified$`, string(lines[4]))
+				assert.Regexp(t, `^zeta\s+https://zeta.example.com\s+zeta-model\s+prod,eu\s+sk-a\*\*\*\*wxyz$`, string(lines[5]))
+			},
+		},
+		{
+			name:     "unknown format",
+			output:   "xml",
+			wantCode: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "config.toml")
+			require.NoError(t, os.WriteFile(configPath, []byte(listConfigTOML), 0600))
+			t.Setenv("CCCTX_CONFIG_PATH", configPath)
+			t.Setenv("CCCTX_TEST_LIST_TOKEN", token)
+
+			var stdout bytes.Buffer
+			code := listRun(tt.output, &stdout)
+			assert.Equal(t, tt.wantCode, code)
+			assert.NotContains(t, stdout.String(), token)
+			if tt.check != nil {
+				tt.check(t, stdout.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,213 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dsdashun/ccctx/config"
+	"github.com/dsdashun/ccctx/internal/gateway"
+	"github.com/dsdashun/ccctx/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+type modelsOptions struct {
+	refresh bool
+	set     string
+	output  string
+	timeout time.Duration
+}
+
+var modelsOpts modelsOptions
+
+// modelsCacheMaxAge is how long a fetched model list is reused.
+const modelsCacheMaxAge = 24 * time.Hour
+
+// modelSlots maps the names --set accepts to the fields they write.
+var modelSlots = []struct{ name, key string }{
+	{"model", "model"},
+	{"haiku", "haiku_model"},
+	{"sonnet", "sonnet_model"},
+	{"opus", "opus_model"},
+}
+
+var ModelsCmd = &cobra.Command{
+	Use:   "models <context>",
+	Short: "List the models a context's gateway offers",
+	Long: `List the models returned by GET /v1/models on the context's base_url, marking those the context uses.
+
+Lists are cached in models-cache.json next to the config file for 24 hours; --refresh fetches the list again. With --set model, haiku, sonnet or opus, pick one of the models and write it to that field of the context.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeContext,
+	Run: func(cmd *cobra.Command, args []string) {
+		os.Exit(modelsRun(args[0], modelsOpts, os.Stdout))
+	},
+}
+
+func init() {
+	flags := ModelsCmd.Flags()
+	flags.BoolVar(&modelsOpts.refresh, "refresh", false, "fetch the list again instead of using the cache")
+	flags.StringVar(&modelsOpts.set, "set", "", "pick a model and set it as the context's model, haiku, sonnet or opus model")
+	flags.StringVarP(&modelsOpts.output, "output", "o", "", "output format: json")
+	flags.DurationVar(&modelsOpts.timeout, "timeout", 30*time.Second, "how long to wait for the gateway")
+	slots := make([]cobra.Completion, len(modelSlots))
+	for i, slot := range modelSlots {
+		slots[i] = slot.name
+	}
+	_ = ModelsCmd.RegisterFlagCompletionFunc("set", cobra.FixedCompletions(slots, cobra.ShellCompDirectiveNoFileComp))
+	_ = ModelsCmd.RegisterFlagCompletionFunc("output", cobra.FixedCompletions([]cobra.Completion{"json"}, cobra.ShellCompDirectiveNoFileComp))
+}
+
+func modelsRun(name string, opts modelsOptions, stdout io.Writer) int {
+	if opts.output != "" && opts.output != "json" {
+		fmt.Fprintf(os.Stderr, "Error: unknown output format '%s': use json\n", opts.output)
+		return 1
+	}
+	key := ""
+	if opts.set != "" {
+		i := slices.IndexFunc(modelSlots, func(s struct{ name, key string }) bool { return s.name == opts.set })
+		if i < 0 {
+			fmt.Fprintf(os.Stderr, "Error: unknown model slot '%s': use model, haiku, sonnet or opus\n", opts.set)
+			return 1
+		}
+		key = modelSlots[i].key
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	ctx, err := gatewayContext(cfg, name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	models, err := contextModels(name, ctx, opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	if key != "" {
+		entries := make([]ui.Entry, len(models))
+		for i, m := range models {
+			entries[i] = ui.Entry{Name: m.ID, Description: m.DisplayName}
+		}
+		chosen, err := selectModel(entries)
+		if err != nil {
+			if errors.Is(err, ui.ErrCancelled) {
+				fmt.Fprintln(os.Stderr, "Operation cancelled.")
+				return 1
+			}
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		if err := config.SetField(name, key, chosen); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		fmt.Fprintf(stdout, "Set %s in context '%s' to '%s'.\n", key, name, chosen)
+		return 0
+	}
+
+	if opts.output == "json" {
+		if models == nil {
+			models = []gateway.Model{}
+		}
+		data, err := json.MarshalIndent(models, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		fmt.Fprintln(stdout, string(data))
+		return 0
+	}
+
+	if len(models) == 0 {
+		fmt.Fprintln(stdout, "No models found.")
+		return 0
+	}
+	w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tUSED AS")
+	for _, m := range models {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", m.ID, m.DisplayName, strings.Join(usedAs(ctx, m.ID), ", "))
+	}
+	w.Flush()
+	return 0
+}
+
+// contextModels returns the models of the context's gateway, from the cache
+// unless it is stale or opts asks for a refresh. Failing to update the cache
+// only warns.
+func contextModels(name string, ctx *config.Context, opts modelsOptions) ([]gateway.Model, error) {
+	cachePath, err := config.GetModelsCachePath()
+	if err != nil {
+		return nil, err
+	}
+	if !opts.refresh {
+		if models, fetched := gateway.CachedModels(cachePath, name, ctx.BaseURL, modelsCacheMaxAge); models != nil {
+			if opts.output != "json" {
+				fmt.Fprintf(os.Stderr, "Using models cached %s; pass --refresh to fetch them again.\n", ui.Ago(fetched, time.Now()))
+			}
+			return models, nil
+		}
+	}
+
+	client, err := gatewayClient(name, ctx)
+	if err != nil {
+		return nil, err
+	}
+	reqCtx, cancel := context.WithTimeout(context.Background(), opts.timeout)
+	defer cancel()
+	models, err := gateway.ListModels(reqCtx, client, ctx.BaseURL, ctx.AuthToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list models for context '%s': %w", name, err)
+	}
+	if err := gateway.CacheModels(cachePath, name, ctx.BaseURL, models); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to cache models: %v\n", err)
+	}
+	return models, nil
+}
+
+// usedAs names the --set slots in which the context uses model, directly or
+// through an alias.
+func usedAs(ctx *config.Context, model string) []string {
+	haiku := ctx.HaikuModel
+	if haiku == "" {
+		haiku = ctx.SmallFastModel
+	}
+	var slots []string
+	for _, s := range []struct{ name, value string }{
+		{"model", ctx.Model},
+		{"haiku", haiku},
+		{"sonnet", ctx.SonnetModel},
+		{"opus", ctx.OpusModel},
+	} {
+		if id, _ := ctx.ExpandModel(s.value); id == model {
+			slots = append(slots, s.name)
+		}
+	}
+	return slots
+}
+
+// selectModel asks the user for a model the way selectContext asks for a
+// context, except that $CCCTX_PICKER is not used.
+func selectModel(entries []ui.Entry) (string, error) {
+	switch {
+	case len(entries) == 0:
+		return "", fmt.Errorf("the gateway lists no models")
+	case isTerminal(os.Stdin) && isTerminal(os.Stderr) && os.Getenv("TERM") != "dumb":
+		return ui.RunModelSelector(entries)
+	case hasInput(os.Stdin):
+		return ui.PromptModel(entries, os.Stdin, os.Stderr)
+	}
+	return "", fmt.Errorf("no terminal to select a model in; use 'ccctx set' with one of the listed models")
+}
@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dsdashun/ccctx/config"
+	"github.com/dsdashun/ccctx/internal/gateway"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestModelsRun(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.Header.Get("X-Gateway-Team") != "core" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Write([]byte(`{"data":[{"id":"claude-opus-4-7","display_name":"Claude Opus 4.7"},{"id":"claude-sonnet-4-6","display_name":"Claude Sonnet 4.6"}],"has_more":false}`))
+	}))
+	defer server.Close()
+
+	configPath := filepath.Join(t.TempDir(), "config.toml")
+	configTOML := `[models]
+sonnet = "claude-sonnet-4-6"
+
+[context.work]
+base_url = "` + server.URL + `"
+auth_token = "work-token"
+model = "sonnet"
+sonnet_model = "claude-sonnet-4-6"
+
+[context.work.env]
+ANTHROPIC_CUSTOM_HEADERS = "X-Gateway-Team: core"
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(configTOML), 0600))
+	t.Setenv("CCCTX_CONFIG_PATH", configPath)
+
+	var out bytes.Buffer
+	require.Equal(t, 0, modelsRun("work", modelsOptions{timeout: time.Minute}, &out))
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 3)
+	assert.Equal(t, []string{"ID", "NAME", "USED", "AS"}, strings.Fields(lines[0]))
+	assert.Equal(t, []string{"claude-opus-4-7", "Claude", "Opus", "4.7"}, strings.Fields(lines[1]))
+	assert.Equal(t, []string{"claude-sonnet-4-6", "Claude", "Sonnet", "4.6", "model,", "sonnet"}, strings.Fields(lines[2]))
+	assert.Equal(t, int32(1), requests.Load())
+
+	out.Reset()
+	require.Equal(t, 0, modelsRun("work", modelsOptions{output: "json", timeout: time.Minute}, &out))
+	var models []gateway.Model
+	require.NoError(t, json.Unmarshal(out.Bytes(), &models))
+	assert.Len(t, models, 2)
+	assert.Equal(t, int32(1), requests.Load(), "the cached list is reused")
+
+	require.Equal(t, 0, modelsRun("work", modelsOptions{refresh: true, output: "json", timeout: time.Minute}, &bytes.Buffer{}))
+	assert.Equal(t, int32(2), requests.Load())
+
+	// --set picks from a numbered prompt when stdin is not a terminal
+	stdinPath := filepath.Join(t.TempDir(), "stdin")
+	require.NoError(t, os.WriteFile(stdinPath, []byte("1\n"), 0600))
+	stdin, err := os.Open(stdinPath)
+	require.NoError(t, err)
+	originalStdin := os.Stdin
+	os.Stdin = stdin
+	t.Cleanup(func() {
+		os.Stdin = originalStdin
+		stdin.Close()
+	})
+
+	out.Reset()
+	require.Equal(t, 0, modelsRun("work", modelsOptions{set: "opus", timeout: time.Minute}, &out))
+	assert.Equal(t, "Set opus_model in context 'work' to 'claude-opus-4-7'.\n", out.String())
+	ctx, err := config.GetContext("work")
+	require.NoError(t, err)
+	assert.Equal(t, "claude-opus-4-7", ctx.OpusModel)
+
+	assert.Equal(t, 1, modelsRun("work", modelsOptions{set: "fast"}, &out))
+	assert.Equal(t, 1, modelsRun("missing", modelsOptions{timeout: time.Minute}, &out))
+}
@@ -0,0 +1,191 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dsdashun/ccctx/config"
+	"github.com/dsdashun/ccctx/internal/gateway"
+	"github.com/dsdashun/ccctx/internal/runner"
+	"github.com/spf13/cobra"
+)
+
+type pingOptions struct {
+	timeout time.Duration
+	output  string
+}
+
+var pingOpts pingOptions
+
+var PingCmd = &cobra.Command{
+	Use:   "ping [context...]",
+	Short: "Check that contexts reach their gateway and their tokens are accepted",
+	Long: `Send a minimal authenticated request, GET /v1/models, to the base_url of each given context, or of every context using the anthropic provider, with its auth token and the proxy, CA certificates and custom headers its environment sets, and report the HTTP status, the latency and whether the token was rejected.
+
+Tokens are resolved one context at a time, so prompts from vault: or cmd: references do not overlap; the requests then run concurrently, each limited by --timeout. ping exits with status 1 when any context fails.`,
+	ValidArgsFunction: completeContexts,
+	Run: func(cmd *cobra.Command, args []string) {
+		os.Exit(pingRun(args, pingOpts, os.Stdout))
+	},
+}
+
+func init() {
+	flags := PingCmd.Flags()
+	flags.DurationVar(&pingOpts.timeout, "timeout", 10*time.Second, "how long to wait for each gateway")
+	flags.StringVarP(&pingOpts.output, "output", "o", "", "output format: json")
+	_ = PingCmd.RegisterFlagCompletionFunc("output", cobra.FixedCompletions([]cobra.Completion{"json"}, cobra.ShellCompDirectiveNoFileComp))
+}
+
+// pingRecord is the outcome for one context in ping's JSON output.
+type pingRecord struct {
+	Context    string `json:"context"`
+	OK         bool   `json:"ok"`
+	StatusCode int    `json:"status_code,omitempty"`
+	Latency    string `json:"latency,omitempty"`
+	AuthFailed bool   `json:"auth_failed,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+func pingRun(args []string, opts pingOptions, stdout io.Writer) int {
+	if opts.output != "" && opts.output != "json" {
+		fmt.Fprintf(os.Stderr, "Error: unknown output format '%s': use json\n", opts.output)
+		return 1
+	}
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	names := args
+	if len(names) == 0 {
+		all, err := config.ListContexts()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		// Bedrock and Vertex contexts have no gateway to ping
+		for _, name := range all {
+			if ctx, err := cfg.Resolve(name); err == nil && (ctx.Provider == config.ProviderBedrock || ctx.Provider == config.ProviderVertex) {
+				continue
+			}
+			names = append(names, name)
+		}
+	}
+
+	records := make([]pingRecord, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		records[i].Context = name
+		ctx, err := gatewayContext(cfg, name)
+		if err != nil {
+			records[i].Error = err.Error()
+			continue
+		}
+		client, err := gatewayClient(name, ctx)
+		if err != nil {
+			records[i].Error = err.Error()
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			reqCtx, cancel := context.WithTimeout(context.Background(), opts.timeout)
+			defer cancel()
+			result := gateway.Ping(reqCtx, client, ctx.BaseURL, ctx.AuthToken)
+			records[i] = pingRecord{
+				Context:    name,
+				OK:         result.OK(),
+				StatusCode: result.StatusCode,
+				AuthFailed: result.AuthFailed(),
+				Error:      pingError(result),
+			}
+			if result.StatusCode != 0 {
+				records[i].Latency = roundDuration(result.Latency)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if opts.output == "json" {
+		data, err := json.MarshalIndent(records, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		fmt.Fprintln(stdout, string(data))
+	} else {
+		w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "CONTEXT\tSTATUS\tLATENCY\tRESULT")
+		for _, r := range records {
+			status, latency, result := "-", "-", "ok"
+			if r.StatusCode != 0 {
+				status, latency = strconv.Itoa(r.StatusCode), r.Latency
+			}
+			switch {
+			case r.AuthFailed:
+				result = "auth failed: " + r.Error
+			case !r.OK:
+				result = "error: " + r.Error
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.Context, status, latency, result)
+		}
+		w.Flush()
+	}
+
+	for _, r := range records {
+		if !r.OK {
+			return 1
+		}
+	}
+	return 0
+}
+
+// gatewayContext resolves a context to send requests with, rejecting those
+// run would reject and those not using the anthropic provider, whose gateway
+// ccctx cannot query.
+func gatewayContext(cfg *config.Config, name string) (*config.Context, error) {
+	ctx, err := cfg.Context(name)
+	if err != nil {
+		return nil, err
+	}
+	if err := runner.CheckContext(name, ctx); err != nil {
+		return nil, err
+	}
+	if provider := ctx.ProviderName(); provider != config.ProviderAnthropic {
+		return nil, fmt.Errorf("context '%s' uses provider %s, which has no gateway to query", name, provider)
+	}
+	return ctx, nil
+}
+
+// gatewayClient returns a client that reaches the context's gateway through
+// the proxy, CA certificates and custom headers its environment gives claude.
+func gatewayClient(name string, ctx *config.Context) (*http.Client, error) {
+	client, err := gateway.NewClient(runner.EnvironFor(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("context '%s': %w", name, err)
+	}
+	return client, nil
+}
+
+// pingError describes a failed ping: the transport error, the message of
+// the error response, or failing that its status.
+func pingError(result gateway.Result) string {
+	switch {
+	case result.Err != nil:
+		return result.Err.Error()
+	case result.OK():
+		return ""
+	case result.Message != "":
+		return result.Message
+	default:
+		return http.StatusText(result.StatusCode)
+	}
+}
@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPingRun(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Header.Get("Authorization") {
+		case "Bearer good-token":
+			w.Write([]byte(`{"data":[]}`))
+		case "Bearer slow-token":
+			<-release
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"type":"error","error":{"type":"authentication_error","message":"invalid x-api-key"}}`))
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	configPath := filepath.Join(t.TempDir(), "config.toml")
+	configTOML := `[context.work]
+base_url = "` + server.URL + `"
+auth_token = "env:WORK_TOKEN"
+
+[context.revoked]
+base_url = "` + server.URL + `"
+auth_token = "revoked-token"
+
+[context.slow]
+base_url = "` + server.URL + `"
+auth_token = "slow-token"
+
+[context.broken]
+base_url = "` + server.URL + `"
+
+[context.aws]
+provider = "bedrock"
+aws_region = "us-east-1"
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(configTOML), 0600))
+	t.Setenv("CCCTX_CONFIG_PATH", configPath)
+	t.Setenv("WORK_TOKEN", "good-token")
+	opts := pingOptions{timeout: 200 * time.Millisecond}
+
+	var out bytes.Buffer
+	assert.Equal(t, 0, pingRun([]string{"work"}, opts, &out))
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.Equal(t, []string{"CONTEXT", "STATUS", "LATENCY", "RESULT"}, strings.Fields(lines[0]))
+	fields := strings.Fields(lines[1])
+	assert.Equal(t, []string{"work", "200"}, fields[:2])
+	assert.Equal(t, "ok", fields[3])
+
+	out.Reset()
+	opts.output = "json"
+	start := time.Now()
+	assert.Equal(t, 1, pingRun(nil, opts, &out))
+	assert.Less(t, time.Since(start), 2*time.Second, "pings time out")
+	var records []pingRecord
+	require.NoError(t, json.Unmarshal(out.Bytes(), &records))
+	require.Len(t, records, 4)
+	for i := range records {
+		if records[i].StatusCode != 0 {
+			assert.NotEmpty(t, records[i].Latency)
+			records[i].Latency = ""
+		}
+	}
+	assert.Equal(t, pingRecord{Context: "broken", Error: "context 'broken' is missing auth_token"}, records[0])
+	assert.Equal(t, pingRecord{Context: "revoked", StatusCode: 401, AuthFailed: true, Error: "invalid x-api-key"}, records[1])
+	assert.Equal(t, "slow", records[2].Context)
+	assert.False(t, records[2].OK)
+	assert.Contains(t, records[2].Error, "context deadline exceeded")
+	assert.Equal(t, pingRecord{Context: "work", OK: true, StatusCode: 200}, records[3])
+
+	out.Reset()
+	opts.output = ""
+	assert.Equal(t, 1, pingRun([]string{"revoked", "missing"}, opts, &out))
+	assert.Contains(t, out.String(), "auth failed: invalid x-api-key")
+	assert.Contains(t, out.String(), "error: context 'missing' not found")
+
+	// Bedrock and Vertex contexts are only pinged when named
+	out.Reset()
+	assert.Equal(t, 1, pingRun([]string{"aws"}, opts, &out))
+	assert.Contains(t, out.String(), "error: context 'aws' uses provider bedrock, which has no gateway to query")
+}
+
+func TestPingRun_ContextEnv(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Gateway-Team") != "core" {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"type":"error","error":{"type":"permission_error","message":"missing team header"}}`))
+			return
+		}
+		w.Write([]byte(`{"data":[]}`))
+	}))
+	defer server.Close()
+
+	configPath := filepath.Join(t.TempDir(), "config.toml")
+	configTOML := `[context.work]
+base_url = "` + server.URL + `"
+auth_token = "work-token"
+
+[context.work.env]
+ANTHROPIC_CUSTOM_HEADERS = "X-Gateway-Team: core"
+
+[context.bare]
+base_url = "` + server.URL + `"
+auth_token = "work-token"
+
+[context.badca]
+base_url = "` + server.URL + `"
+auth_token = "work-token"
+
+[context.badca.env]
+NODE_EXTRA_CA_CERTS = "/nonexistent/ca.pem"
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(configTOML), 0600))
+	t.Setenv("CCCTX_CONFIG_PATH", configPath)
+	opts := pingOptions{timeout: time.Minute, output: "json"}
+
+	var out bytes.Buffer
+	assert.Equal(t, 1, pingRun([]string{"work", "bare", "badca"}, opts, &out))
+	var records []pingRecord
+	require.NoError(t, json.Unmarshal(out.Bytes(), &records))
+	require.Len(t, records, 3)
+	assert.True(t, records[0].OK, records[0].Error)
+	assert.Equal(t, "missing team header", records[1].Error)
+	assert.Contains(t, records[2].Error, "context 'badca': failed to read NODE_EXTRA_CA_CERTS")
+}
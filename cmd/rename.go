@@ -0,0 +1,24 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/dsdashun/ccctx/config"
+	"github.com/spf13/cobra"
+)
+
+var RenameCmd = &cobra.Command{
+	Use:               "rename <context> <new-name>",
+	Short:             "Rename a context",
+	Long:              "Rename a context in the configuration file, updating contexts that extend it and the current context.",
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeContext,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := config.RenameContext(args[0], args[1]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Context '%s' renamed to '%s'.\n", args[0], args[1])
+	},
+}
@@ -0,0 +1,25 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/dsdashun/ccctx/config"
+	"github.com/spf13/cobra"
+)
+
+var RmCmd = &cobra.Command{
+	Use:               "rm <context>",
+	Aliases:           []string{"remove"},
+	Short:             "Remove a context",
+	Long:              "Remove a context from the configuration file. A context that other contexts extend cannot be removed.",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeContext,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := config.RemoveContext(args[0]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Context '%s' removed.\n", args[0])
+	},
+}
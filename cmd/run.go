@@ -5,8 +5,10 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"time"
 
 	"github.com/dsdashun/ccctx/config"
+	"github.com/dsdashun/ccctx/internal/history"
 	"github.com/dsdashun/ccctx/internal/runner"
 	"github.com/dsdashun/ccctx/internal/ui"
 	"github.com/spf13/cobra"
@@ -15,9 +17,10 @@ import (
 var RunCmd = &cobra.Command{
 	Use:                "run [context] [-- claude-args...]",
 	Short:              "Run claude with a context",
-	Long:               "Run claude with the specified context or interactively select one. Arguments after '--' are passed to claude.",
+	Long:               "Run claude with the specified context. Without one, uses the current context set by 'ccctx use', or opens the interactive selector if none is set or --select is given. With --dry-run, prints the command and environment changes instead of running claude. With --replace, ccctx replaces itself with claude instead of waiting for it, which is not supported on Windows. Arguments after '--' are passed to claude.",
 	Args:               cobra.ArbitraryArgs,
 	DisableFlagParsing: true,
+	ValidArgsFunction:  completeRunArgs,
 	Run: func(cmd *cobra.Command, args []string) {
 		if runner.WantsHelp(args) {
 			if err := cmd.Help(); err != nil {
@@ -30,7 +33,7 @@ var RunCmd = &cobra.Command{
 }
 
 func runRun(args []string) int {
-	model, haikuModel, sonnetModel, opusModel, args, err := runner.ExtractFlags(args)
+	flags, args, err := runner.ExtractFlags(args)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		return 1
@@ -42,19 +45,10 @@ func runRun(args []string) int {
 		return 1
 	}
 
+	var project *config.Project
+	var filled []string
 	if useTUI {
-		contexts, err := config.ListContexts()
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-			return 1
-		}
-
-		if len(contexts) == 0 {
-			fmt.Fprintf(os.Stderr, "Error: no contexts found\n")
-			return 1
-		}
-
-		provider, err = ui.RunContextSelector(contexts)
+		provider, project, err = chooseContext(flags.Select)
 		if err != nil {
 			if errors.Is(err, ui.ErrCancelled) {
 				fmt.Fprintln(os.Stderr, "Operation cancelled.")
@@ -63,12 +57,17 @@ func runRun(args []string) int {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			return 1
 		}
+		filled = applyProject(&flags, project)
 	}
 
 	claudePath, err := exec.LookPath("claude")
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: claude not found in PATH\n")
-		return 1
+		if !flags.DryRun {
+			fmt.Fprintf(os.Stderr, "Error: claude not found in PATH\n")
+			return 1
+		}
+		// A dry run only shows the command, so it works without claude
+		claudePath = "claude"
 	}
 
 	target := append([]string{claudePath}, targetArgs...)
@@ -76,20 +75,36 @@ func runRun(args []string) int {
 	r, err := runner.New(runner.Options{
 		ContextName: provider,
 		Target:      target,
-		Model:       model,
-		HaikuModel:  haikuModel,
-		SonnetModel: sonnetModel,
-		OpusModel:   opusModel,
+		Model:       flags.Model,
+		HaikuModel:  flags.HaikuModel,
+		SonnetModel: flags.SonnetModel,
+		OpusModel:   flags.OpusModel,
+
+		ForwardSignals: true,
 	})
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		return 1
 	}
 
+	if flags.DryRun {
+		if err := printDryRun(r, provider, filled, project, os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	if flags.Replace {
+		return replaceRun(r, provider)
+	}
+
+	start := time.Now()
 	exitCode, err := r.Run()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		return 1
+		exitCode = 1
 	}
+	recordRun(history.Entry{Context: provider, Start: start, Command: r.Command(), ExitCode: exitCode, Duration: time.Since(start)})
 	return exitCode
 }
@@ -6,6 +6,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/dsdashun/ccctx/internal/history"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -348,3 +349,117 @@ func TestRunRun_ModelFlags(t *testing.T) {
 		})
 	}
 }
+
+func TestRunRun_CurrentContext(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.toml")
+	configTOML := `[context.work]
+base_url = "https://api.example.com"
+auth_token = "test-token"
+model = "work-model"
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(configTOML), 0600))
+	t.Setenv("CCCTX_CONFIG_PATH", configPath)
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "state.json"), []byte(`{"current": "work"}`), 0600))
+
+	outputFile := filepath.Join(t.TempDir(), "mock_output")
+	t.Setenv("MOCK_OUTPUT_FILE", outputFile)
+	mockDir := t.TempDir()
+	writeModelMock(t, mockDir, "claude")
+	t.Setenv("PATH", mockDir)
+
+	code := runRun([]string{"--", "--version"})
+	assert.Equal(t, 0, code)
+	assertModelOutput(t, outputFile, "work-model", "", "", "")
+
+	entries, err := history.Load(filepath.Join(tmpDir, "history.jsonl"))
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "work", entries[0].Context)
+	assert.Equal(t, []string{filepath.Join(mockDir, "claude")}, entries[0].Command, "arguments are not recorded")
+	assert.Equal(t, 0, entries[0].ExitCode)
+}
+
+func TestRunRun_ProjectFile(t *testing.T) {
+	tests := []struct {
+		name        string
+		projectFile string
+		content     string
+		args        []string
+		wantCode    int
+		wantModel   string
+		wantOpus    string
+	}{
+		{
+			name:        "project file overrides current context",
+			projectFile: ".ccctx.toml",
+			content:     "context = \"project\"\nopus_model = \"project-opus\"\n",
+			wantCode:    0,
+			wantModel:   "project-model",
+			wantOpus:    "project-opus",
+		},
+		{
+			name:        "model flag wins over project file",
+			projectFile: ".ccctx.toml",
+			content:     "context = \"project\"\nopus_model = \"project-opus\"\n",
+			args:        []string{"--opus-model", "flag-opus"},
+			wantCode:    0,
+			wantModel:   "project-model",
+			wantOpus:    "flag-opus",
+		},
+		{
+			name:        "plain project file",
+			projectFile: ".ccctx",
+			content:     "project\n",
+			wantCode:    0,
+			wantModel:   "project-model",
+		},
+		{
+			name:        "project file naming an unknown context",
+			projectFile: ".ccctx",
+			content:     "missing\n",
+			wantCode:    1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			configPath := filepath.Join(tmpDir, "config.toml")
+			configTOML := `[context.current]
+base_url = "https://api.example.com"
+auth_token = "test-token"
+model = "current-model"
+
+[context.project]
+base_url = "https://api.example.com"
+auth_token = "test-token"
+model = "project-model"
+`
+			require.NoError(t, os.WriteFile(configPath, []byte(configTOML), 0600))
+			t.Setenv("CCCTX_CONFIG_PATH", configPath)
+			require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "state.json"), []byte(`{"current": "current"}`), 0600))
+
+			repoDir := filepath.Join(t.TempDir(), "repo")
+			workDir := filepath.Join(repoDir, "src")
+			require.NoError(t, os.MkdirAll(workDir, 0755))
+			require.NoError(t, os.WriteFile(filepath.Join(repoDir, tt.projectFile), []byte(tt.content), 0644))
+			originalDir, err := os.Getwd()
+			require.NoError(t, err)
+			require.NoError(t, os.Chdir(workDir))
+			t.Cleanup(func() { os.Chdir(originalDir) })
+
+			outputFile := filepath.Join(t.TempDir(), "mock_output")
+			t.Setenv("MOCK_OUTPUT_FILE", outputFile)
+			mockDir := t.TempDir()
+			writeModelMock(t, mockDir, "claude")
+			t.Setenv("PATH", mockDir)
+
+			code := runRun(tt.args)
+			assert.Equal(t, tt.wantCode, code)
+			if tt.wantCode == 0 {
+				assertModelOutput(t, outputFile, tt.wantModel, "", "", tt.wantOpus)
+			}
+		})
+	}
+}
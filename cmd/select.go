@@ -0,0 +1,178 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/dsdashun/ccctx/config"
+	"github.com/dsdashun/ccctx/internal/mask"
+	"github.com/dsdashun/ccctx/internal/runner"
+	"github.com/dsdashun/ccctx/internal/ui"
+	"golang.org/x/term"
+)
+
+// defaultContext returns the context used when none is given on the command
+// line: the one bound by a project file in or above the working directory,
+// else the current context set by 'ccctx use'. project is nil unless a project
+// file made the choice, and name is "" when neither is configured.
+func defaultContext() (name string, project *config.Project, err error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return "", nil, err
+	}
+	project, err = config.FindProject(wd)
+	if err != nil {
+		return "", nil, err
+	}
+	if project != nil {
+		contexts, err := config.ListContexts()
+		if err != nil {
+			return "", nil, err
+		}
+		if !slices.Contains(contexts, project.Context) {
+			return "", nil, fmt.Errorf("context '%s' from '%s' not found", project.Context, project.Path)
+		}
+		return project.Context, project, nil
+	}
+
+	name, err = config.GetCurrentContext()
+	return name, nil, err
+}
+
+// chooseContext returns the context to run with when ParseArgs found none:
+// the default context, or one picked in the interactive selector when there is
+// none or forceSelect is set.
+func chooseContext(forceSelect bool) (string, *config.Project, error) {
+	if !forceSelect {
+		name, project, err := defaultContext()
+		if err != nil || name != "" {
+			return name, project, err
+		}
+	}
+	name, err := selectContext()
+	return name, nil, err
+}
+
+// selectContext asks the user for a context: with the picker named by
+// $CCCTX_PICKER when set, else the interactive selector on a capable terminal,
+// else a numbered prompt on stderr that reads the answer from stdin. Without
+// usable stdin it fails, listing the contexts to pass instead.
+func selectContext() (string, error) {
+	entries, err := selectorEntries()
+	if err != nil {
+		return "", err
+	}
+	if len(entries) == 0 {
+		return "", fmt.Errorf("no contexts found")
+	}
+
+	if picker := os.Getenv("CCCTX_PICKER"); picker != "" {
+		return ui.RunPicker(picker, entries)
+	}
+	switch {
+	case isTerminal(os.Stdin) && isTerminal(os.Stderr) && os.Getenv("TERM") != "dumb":
+		return ui.RunContextSelector(entries)
+	case hasInput(os.Stdin):
+		return ui.PromptContext(entries, os.Stdin, os.Stderr)
+	}
+	names := make([]string, len(entries))
+	for i, e := range entries {
+		names[i] = e.Name
+	}
+	return "", fmt.Errorf("no context given and no terminal to select one in; pass one of: %s", strings.Join(names, ", "))
+}
+
+func isTerminal(f *os.File) bool {
+	return term.IsTerminal(int(f.Fd()))
+}
+
+// hasInput reports whether f can supply an answer: a terminal, pipe or file,
+// but not a closed descriptor or a device such as /dev/null.
+func hasInput(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	if info.Mode()&os.ModeCharDevice != 0 {
+		return isTerminal(f)
+	}
+	return true
+}
+
+// selectorEntries describes every context for the selector, most recently
+// used first and the never used ones by name. Contexts that fail to resolve
+// are still listed, so picking one reports the error. Secret
+// references are shown rather than resolved, which could prompt for the vault
+// passphrase; literal tokens are masked.
+func selectorEntries() ([]ui.Entry, error) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+	contexts, err := config.ListContexts()
+	if err != nil {
+		return nil, err
+	}
+	used := lastUsed()
+	entries := make([]ui.Entry, 0, len(contexts))
+	for _, name := range contexts {
+		entry := ui.Entry{Name: name, LastUsed: used[name]}
+		if ctx, err := cfg.Resolve(name); err == nil {
+			entry.Description = ctx.Description
+			entry.Tags = ctx.Tags
+			entry.BaseURL = ctx.BaseURL
+			entry.Model = ctx.Model
+			entry.HaikuModel = ctx.HaikuModel
+			if entry.HaikuModel == "" {
+				entry.HaikuModel = ctx.SmallFastModel
+			}
+			entry.SonnetModel = ctx.SonnetModel
+			entry.OpusModel = ctx.OpusModel
+			entry.Token = ctx.AuthToken
+			if !config.IsReference(ctx.AuthToken) {
+				entry.Token = mask.Secret(ctx.AuthToken)
+			}
+		}
+		entries = append(entries, entry)
+	}
+	slices.SortStableFunc(entries, func(a, b ui.Entry) int {
+		return b.LastUsed.Compare(a.LastUsed)
+	})
+	return entries, nil
+}
+
+// applyProject fills model overrides not given as flags from the project file
+// and returns the names of the flags it filled.
+func applyProject(flags *runner.Flags, project *config.Project) []string {
+	if project == nil {
+		return nil
+	}
+	var filled []string
+	for _, f := range []struct {
+		flag  string
+		dst   *string
+		value string
+	}{
+		{"--model", &flags.Model, project.Model},
+		{"--haiku-model", &flags.HaikuModel, project.HaikuModel},
+		{"--sonnet-model", &flags.SonnetModel, project.SonnetModel},
+		{"--opus-model", &flags.OpusModel, project.OpusModel},
+	} {
+		if *f.dst == "" && f.value != "" {
+			*f.dst = f.value
+			filled = append(filled, f.flag)
+		}
+	}
+	return filled
+}
+
+// projectSource credits a value set from the project file to that file
+// instead of the flag it was passed as.
+func projectSource(source string, filled []string, project *config.Project) string {
+	if slices.Contains(filled, source) {
+		return project.Path
+	}
+	return source
+}
@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dsdashun/ccctx/config"
+	"github.com/dsdashun/ccctx/internal/history"
+	"github.com/dsdashun/ccctx/internal/ui"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelectorEntries(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.toml")
+	configTOML := `[context.work]
+description = "Company gateway"
+tags = ["work"]
+base_url = "https://gateway.example.com"
+auth_token = "sk-ant-REDACTED"
+model = "work-model"
+small_fast_model = "work-haiku"
+
+[context.personal]
+base_url = "https://api.anthropic.com"
+auth_token = "vault:personal"
+opus_model = "personal-opus"
+
+[context.broken]
+extends = "missing"
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(configTOML), 0600))
+	t.Setenv("CCCTX_CONFIG_PATH", configPath)
+	historyPath, err := config.GetHistoryPath()
+	require.NoError(t, err)
+	personalUsed := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+	workUsed := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+	for _, e := range []history.Entry{
+		{Context: "work", Start: workUsed.Add(-time.Hour)},
+		{Context: "personal", Start: personalUsed},
+		{Context: "work", Start: workUsed},
+	} {
+		require.NoError(t, history.Append(historyPath, e, 10))
+	}
+
+	entries, err := selectorEntries()
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+
+	assert.Equal(t, ui.Entry{
+		Name:        "work",
+		Description: "Company gateway",
+		Tags:        []string{"work"},
+		BaseURL:     "https://gateway.example.com",
+		Model:       "work-model",
+		HaikuModel:  "work-haiku",
+		Token:       "sk-a****wxyz",
+		LastUsed:    workUsed,
+	}, entries[0])
+	assert.Equal(t, ui.Entry{
+		Name:      "personal",
+		BaseURL:   "https://api.anthropic.com",
+		OpusModel: "personal-opus",
+		Token:     "vault:personal",
+		LastUsed:  personalUsed,
+	}, entries[1])
+	assert.Equal(t, ui.Entry{Name: "broken"}, entries[2])
+}
+
+func TestSelectContext_NonInteractive(t *testing.T) {
+	tests := []struct {
+		name    string
+		stdin   string // "" uses /dev/null
+		picker  string
+		want    string
+		wantErr string
+	}{
+		{
+			name:    "no input lists the contexts",
+			wantErr: "no context given and no terminal to select one in; pass one of: personal, work",
+		},
+		{
+			name:  "piped input answers the numbered prompt",
+			stdin: "2\n",
+			want:  "work",
+		},
+		{
+			name:   "external picker",
+			picker: "tail -n 1",
+			want:   "work",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "config.toml")
+			configTOML := "[context.work]\nbase_url = \"https://work.example.com\"\n\n[context.personal]\nbase_url = \"https://personal.example.com\"\n"
+			require.NoError(t, os.WriteFile(configPath, []byte(configTOML), 0600))
+			t.Setenv("CCCTX_CONFIG_PATH", configPath)
+			t.Setenv("CCCTX_PICKER", tt.picker)
+
+			stdin, err := os.Open(os.DevNull)
+			require.NoError(t, err)
+			if tt.stdin != "" {
+				stdinPath := filepath.Join(t.TempDir(), "stdin")
+				require.NoError(t, os.WriteFile(stdinPath, []byte(tt.stdin), 0600))
+				stdin, err = os.Open(stdinPath)
+				require.NoError(t, err)
+			}
+			originalStdin := os.Stdin
+			os.Stdin = stdin
+			t.Cleanup(func() {
+				os.Stdin = originalStdin
+				stdin.Close()
+			})
+
+			got, err := selectContext()
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Equal(t, tt.wantErr, err.Error())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/dsdashun/ccctx/config"
+	"github.com/spf13/cobra"
+)
+
+var fieldHelp = "Fields: " + strings.Join(config.FieldKeys(), ", ") + "."
+
+var SetCmd = &cobra.Command{
+	Use:               "set <context> <field> <value>",
+	Short:             "Set a field of a context",
+	Long:              "Set a field of a context in the configuration file, keeping comments and ordering intact. " + fieldHelp,
+	Args:              cobra.ExactArgs(3),
+	ValidArgsFunction: completeField,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := config.SetField(args[0], args[1], args[2]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Set %s in context '%s'.\n", args[1], args[0])
+	},
+}
+
+var UnsetCmd = &cobra.Command{
+	Use:               "unset <context> <field>",
+	Short:             "Remove a field from a context",
+	Long:              "Remove a field from a context in the configuration file, so it is inherited again. " + fieldHelp,
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeField,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := config.UnsetField(args[0], args[1]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Unset %s in context '%s'.\n", args[1], args[0])
+	},
+}
+
+// completeField completes the context, field and value arguments of set and
+// unset. Values are completed for extends, provider and the model fields
+// only.
+func completeField(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	switch len(args) {
+	case 0:
+		return contextNames(toComplete), cobra.ShellCompDirectiveNoFileComp
+	case 1:
+		var completions []cobra.Completion
+		for _, key := range config.FieldKeys() {
+			key = strings.TrimSuffix(key, "<NAME>")
+			if strings.HasPrefix(key, toComplete) {
+				completions = append(completions, key)
+			}
+		}
+		directive := cobra.ShellCompDirectiveNoFileComp
+		if len(completions) == 1 && completions[0] == "env." {
+			// Leave the cursor after the dot for the variable name
+			directive |= cobra.ShellCompDirectiveNoSpace
+		}
+		return completions, directive
+	case 2:
+		if cmd.Name() != "set" {
+			break
+		}
+		switch args[1] {
+		case "extends":
+			return contextNames(toComplete), cobra.ShellCompDirectiveNoFileComp
+		case "provider":
+			return config.Providers, cobra.ShellCompDirectiveNoFileComp
+		case "model", "small_fast_model", "haiku_model", "sonnet_model", "opus_model":
+			return modelNames(toComplete), cobra.ShellCompDirectiveNoFileComp
+		}
+	}
+	return nil, cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/dsdashun/ccctx/config"
+	"github.com/dsdashun/ccctx/internal/mask"
+	"github.com/dsdashun/ccctx/internal/runner"
+	"github.com/spf13/cobra"
+)
+
+type showOptions struct {
+	flags  runner.Flags
+	reveal bool
+}
+
+var showOpts showOptions
+
+var ShowCmd = &cobra.Command{
+	Use:               "show [context]",
+	Short:             "Show a context's resolved settings",
+	Long:              "Show every field of a context after inheritance and defaults, the environment run and exec would set, and where each value came from. Without a context, shows the current one. Secrets are masked unless --reveal is given.",
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeContext,
+	Run: func(cmd *cobra.Command, args []string) {
+		os.Exit(showRun(args, showOpts, os.Stdout))
+	},
+}
+
+func init() {
+	flags := ShowCmd.Flags()
+	flags.StringVar(&showOpts.flags.Model, "model", "", "show the effect of overriding the model")
+	flags.StringVar(&showOpts.flags.HaikuModel, "haiku-model", "", "show the effect of overriding the Haiku-class model")
+	flags.StringVar(&showOpts.flags.SonnetModel, "sonnet-model", "", "show the effect of overriding the Sonnet-class model")
+	flags.StringVar(&showOpts.flags.OpusModel, "opus-model", "", "show the effect of overriding the Opus-class model")
+	flags.BoolVar(&showOpts.reveal, "reveal", false, "print secrets in full")
+	registerModelCompletions(ShowCmd)
+}
+
+func showRun(args []string, opts showOptions, stdout io.Writer) int {
+	var name string
+	var project *config.Project
+	if len(args) == 1 {
+		name = args[0]
+	} else {
+		var err error
+		name, project, err = defaultContext()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		if name == "" {
+			fmt.Fprintln(os.Stderr, "Error: no context given and no current context set")
+			return 1
+		}
+	}
+
+	ctx, err := config.GetContext(name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	flags := opts.flags
+	filled := applyProject(&flags, project)
+	vars := runner.InjectedEnv(ctx, runner.Options{
+		ContextName: name,
+		Model:       flags.Model,
+		HaikuModel:  flags.HaikuModel,
+		SonnetModel: flags.SonnetModel,
+		OpusModel:   flags.OpusModel,
+	})
+	for i := range vars {
+		vars[i].Source = projectSource(vars[i].Source, filled, project)
+	}
+
+	display := func(value string, secret bool) string {
+		if secret && !opts.reveal {
+			return mask.Secret(value)
+		}
+		return value
+	}
+
+	fmt.Fprintf(stdout, "Context: %s\n", name)
+	if ctx.Extends != "" {
+		fmt.Fprintf(stdout, "Extends: %s\n", ctx.Extends)
+	}
+	if ctx.Description != "" {
+		fmt.Fprintf(stdout, "Description: %s\n", ctx.Description)
+	}
+	if len(ctx.Tags) > 0 {
+		fmt.Fprintf(stdout, "Tags: %s\n", strings.Join(ctx.Tags, ", "))
+	}
+	if project != nil {
+		fmt.Fprintf(stdout, "Project: %s\n", project.Path)
+	}
+
+	fmt.Fprintln(stdout, "\nFields:")
+	w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	row := func(key, value string) {
+		fmt.Fprintf(w, "  %s\t%s\t%s\n", key, value, origin(ctx.Sources[key], ctx.Refs[key]))
+	}
+	for _, key := range config.FieldKeys() {
+		if key == "extends" || key == "description" || strings.HasPrefix(key, "env.") {
+			continue
+		}
+		field := ctx.Field(key)
+		if field != "" {
+			row(key, display(field, key == "auth_token" || ctx.Refs[key] != ""))
+		}
+	}
+	for _, envName := range slices.Sorted(maps.Keys(ctx.Env)) {
+		key := "env." + envName
+		row(key, display(ctx.Env[envName], ctx.Refs[key] != "" || mask.IsSecretName(envName)))
+	}
+	if len(ctx.Unset) > 0 {
+		row("unset", strings.Join(ctx.Unset, ", "))
+	}
+	for _, alias := range slices.Sorted(maps.Keys(ctx.Models)) {
+		row("models."+alias, ctx.Models[alias])
+	}
+	w.Flush()
+
+	fmt.Fprintln(stdout, "\nEnvironment:")
+	for _, v := range vars {
+		from := origin(v.Source, v.Ref)
+		if v.Alias != "" {
+			from += fmt.Sprintf(" (alias '%s' from %s)", v.Alias, ctx.Sources["models."+v.Alias])
+		}
+		fmt.Fprintf(w, "  %s=%s\t%s\n", v.Name, display(v.Value, v.Secret), from)
+	}
+	w.Flush()
+	removed := strings.Join(append([]string{"ANTHROPIC_*"}, runner.ProviderCleared(ctx)...), ", ")
+	if len(ctx.Unset) > 0 {
+		removed += ", " + strings.Join(ctx.Unset, ", ")
+	}
+	fmt.Fprintf(stdout, "  (removed from the inherited environment: %s)\n", removed)
+	return 0
+}
+
+// origin describes where a value came from, e.g. "context.work (env:TOKEN)".
+func origin(source, ref string) string {
+	if ref != "" {
+		return source + " (" + ref + ")"
+	}
+	return source
+}
@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dsdashun/ccctx/internal/runner"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShowRun(t *testing.T) {
+	const token = "sk-ant-REDACTED"
+
+	tests := []struct {
+		name        string
+		args        []string
+		opts        showOptions
+		projectTOML string
+		wantCode    int
+		want        []string
+		wantNot     []string
+	}{
+		{
+			name:     "token is masked and sources are shown",
+			args:     []string{"work"},
+			wantCode: 0,
+			want: []string{
+				"Context: work\nExtends: base\n",
+				"ANTHROPIC_AUTH_TOKEN=sk-a****wxyz",
+				"context.base (env:CCCTX_TEST_SHOW_TOKEN)",
+				"ANTHROPIC_MODEL=default-model",
+				"defaults",
+				"ANTHROPIC_DEFAULT_OPUS_MODEL=work-opus",
+				"HTTPS_PROXY=http://proxy:3128",
+			},
+			wantNot: []string{token},
+		},
+		{
+			name:     "reveal prints the token",
+			args:     []string{"work"},
+			opts:     showOptions{reveal: true},
+			wantCode: 0,
+			want:     []string{"ANTHROPIC_AUTH_TOKEN=" + token},
+		},
+		{
+			name:     "flag overrides are attributed to the flag",
+			args:     []string{"work"},
+			opts:     showOptions{flags: runner.Flags{OpusModel: "flag-opus"}},
+			wantCode: 0,
+			want:     []string{"ANTHROPIC_DEFAULT_OPUS_MODEL=flag-opus", "--opus-model"},
+		},
+		{
+			name:        "project overrides are attributed to the project file",
+			projectTOML: "context = \"work\"\nsonnet_model = \"project-sonnet\"\n",
+			wantCode:    0,
+			want: []string{
+				"Project: ",
+				"ANTHROPIC_DEFAULT_SONNET_MODEL=project-sonnet",
+				".ccctx.toml",
+			},
+		},
+		{
+			name:     "model aliases are expanded and attributed",
+			args:     []string{"work"},
+			opts:     showOptions{flags: runner.Flags{Model: "fast"}},
+			wantCode: 0,
+			want: []string{
+				"models.fast",
+				"ANTHROPIC_MODEL=claude-haiku-4-5",
+				"--model (alias 'fast' from models)",
+			},
+		},
+		{
+			name:     "unknown context",
+			args:     []string{"missing"},
+			wantCode: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "config.toml")
+			configTOML := `[defaults]
+model = "default-model"
+
+[models]
+fast = "claude-haiku-4-5"
+
+[context.base]
+base_url = "https://api.example.com"
+auth_token = "env:CCCTX_TEST_SHOW_TOKEN"
+
+[context.base.env]
+HTTPS_PROXY = "http://proxy:3128"
+
+[context.work]
+extends = "base"
+opus_model = "work-opus"
+`
+			require.NoError(t, os.WriteFile(configPath, []byte(configTOML), 0600))
+			t.Setenv("CCCTX_CONFIG_PATH", configPath)
+			t.Setenv("CCCTX_TEST_SHOW_TOKEN", token)
+
+			if tt.projectTOML != "" {
+				projectDir := t.TempDir()
+				require.NoError(t, os.WriteFile(filepath.Join(projectDir, ".ccctx.toml"), []byte(tt.projectTOML), 0644))
+				originalDir, err := os.Getwd()
+				require.NoError(t, err)
+				require.NoError(t, os.Chdir(projectDir))
+				t.Cleanup(func() { os.Chdir(originalDir) })
+			}
+
+			var stdout bytes.Buffer
+			code := showRun(tt.args, tt.opts, &stdout)
+			assert.Equal(t, tt.wantCode, code)
+			for _, want := range tt.want {
+				assert.Contains(t, stdout.String(), want)
+			}
+			for _, wantNot := range tt.wantNot {
+				assert.NotContains(t, stdout.String(), wantNot)
+			}
+		})
+	}
+}
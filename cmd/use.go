@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/dsdashun/ccctx/config"
+	"github.com/dsdashun/ccctx/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var UseCmd = &cobra.Command{
+	Use:               "use [context | -]",
+	Short:             "Set the current context",
+	Long:              "Set the current context used by run and exec when no context is given. Use '-' to switch back to the previous context. Without arguments, opens the interactive selector.",
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeContext,
+	Run: func(cmd *cobra.Command, args []string) {
+		os.Exit(useRun(args))
+	},
+}
+
+func useRun(args []string) int {
+	var name string
+	if len(args) == 1 {
+		name = args[0]
+	} else {
+		var err error
+		name, err = selectContext()
+		if err != nil {
+			if errors.Is(err, ui.ErrCancelled) {
+				fmt.Fprintln(os.Stderr, "Operation cancelled.")
+				return 1
+			}
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+	}
+
+	state, err := config.UseContext(name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Switched to context '%s'.\n", state.Current)
+	return 0
+}
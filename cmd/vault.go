@@ -0,0 +1,192 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/dsdashun/ccctx/config"
+	"github.com/dsdashun/ccctx/internal/vault"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var VaultCmd = &cobra.Command{
+	Use:   "vault",
+	Short: "Manage the encrypted token vault",
+	Long:  "Manage a passphrase-encrypted vault stored next to config.toml. Reference its secrets from the config as 'vault:<name>'. The passphrase is cached for 15 minutes ($CCCTX_VAULT_TIMEOUT) after unlocking, and read from $CCCTX_VAULT_PASSPHRASE when set.",
+}
+
+var vaultSetCmd = &cobra.Command{
+	Use:   "set <name>",
+	Short: "Store a secret in the vault",
+	Long:  "Store a secret in the vault, creating the vault if needed. The secret is prompted for, or read from stdin when it is not a terminal.",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		os.Exit(vaultSetRun(args[0], os.Stdin))
+	},
+}
+
+var vaultGetCmd = &cobra.Command{
+	Use:   "get <name>",
+	Short: "Print a secret from the vault",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		v, err := openVault(false)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		secret, err := v.Get(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(secret)
+	},
+}
+
+var vaultRmCmd = &cobra.Command{
+	Use:   "rm <name>",
+	Short: "Remove a secret from the vault",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		v, err := openVault(false)
+		if err == nil {
+			err = v.Delete(args[0])
+		}
+		if err == nil {
+			err = v.Save()
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Secret '%s' removed from vault.\n", args[0])
+	},
+}
+
+var vaultListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List secret names in the vault",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		v, err := openVault(false)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		names, err := v.Names()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if len(names) == 0 {
+			fmt.Println("No secrets in vault.")
+			return
+		}
+		for _, name := range names {
+			fmt.Println(name)
+		}
+	},
+}
+
+var vaultRekeyCmd = &cobra.Command{
+	Use:   "rekey",
+	Short: "Change the vault passphrase",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		v, err := openVault(false)
+		if err == nil {
+			err = v.Rekey()
+		}
+		if err == nil {
+			err = v.Save()
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("Vault passphrase changed.")
+	},
+}
+
+var vaultLockCmd = &cobra.Command{
+	Use:   "lock",
+	Short: "Forget the cached vault passphrase",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		path, err := config.GetVaultPath()
+		if err == nil {
+			err = vault.Lock(path)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("Vault locked.")
+	},
+}
+
+func init() {
+	VaultCmd.AddCommand(vaultSetCmd, vaultGetCmd, vaultRmCmd, vaultListCmd, vaultRekeyCmd, vaultLockCmd)
+}
+
+// openVault opens and unlocks the vault. Unless create is set, a missing
+// vault is an error rather than a prompt for a new passphrase.
+func openVault(create bool) (*vault.Vault, error) {
+	path, err := config.GetVaultPath()
+	if err != nil {
+		return nil, err
+	}
+	v, err := vault.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if !v.Exists() && !create {
+		return nil, fmt.Errorf("no vault at '%s'; add secrets with 'ccctx vault set'", path)
+	}
+	if err := v.Unlock(); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func vaultSetRun(name string, stdin io.Reader) int {
+	secret, err := readSecret(name, stdin)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	if secret == "" {
+		fmt.Fprintf(os.Stderr, "Error: secret cannot be empty\n")
+		return 1
+	}
+
+	v, err := openVault(true)
+	if err == nil {
+		err = v.Set(name, secret)
+	}
+	if err == nil {
+		err = v.Save()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	fmt.Printf("Secret '%s' stored in vault. Reference it as auth_token = \"vault:%s\".\n", name, name)
+	return 0
+}
+
+func readSecret(name string, stdin io.Reader) (string, error) {
+	if f, ok := stdin.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		return vault.ReadPassphrase(fmt.Sprintf("Secret for '%s': ", name))
+	}
+	data, err := io.ReadAll(stdin)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dsdashun/ccctx/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVaultSetRun(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.toml")
+	configTOML := "[context.work]\nbase_url = \"https://api.example.com\"\nauth_token = \"vault:work\"\n"
+	require.NoError(t, os.WriteFile(configPath, []byte(configTOML), 0600))
+	t.Setenv("CCCTX_CONFIG_PATH", configPath)
+	t.Setenv("CCCTX_VAULT_PASSPHRASE", "correct horse")
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+
+	assert.Equal(t, 1, vaultSetRun("work", strings.NewReader("\n")))
+	_, err := os.Stat(filepath.Join(tmpDir, "vault.json"))
+	assert.True(t, os.IsNotExist(err), "empty secret must not create the vault")
+
+	require.Equal(t, 0, vaultSetRun("work", strings.NewReader("vault-secret\n")))
+
+	ctx, err := config.GetContext("work")
+	require.NoError(t, err)
+	assert.Equal(t, "vault-secret", ctx.AuthToken)
+}
@@ -1,15 +1,22 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/pelletier/go-toml/v2"
 	"github.com/spf13/viper"
 )
 
 type Context struct {
+	Extends        string `mapstructure:"extends"`
+	Provider       string `mapstructure:"provider"`
 	BaseURL        string `mapstructure:"base_url"`
 	AuthToken      string `mapstructure:"auth_token"`
 	Model          string `mapstructure:"model"`
@@ -17,27 +24,211 @@ type Context struct {
 	HaikuModel     string `mapstructure:"haiku_model"`
 	SonnetModel    string `mapstructure:"sonnet_model"`
 	OpusModel      string `mapstructure:"opus_model"`
+
+	// AWSRegion and AWSProfile configure the bedrock provider, VertexRegion
+	// and VertexProjectID the vertex one. See ProviderName.
+	AWSRegion       string `mapstructure:"aws_region"`
+	AWSProfile      string `mapstructure:"aws_profile"`
+	VertexRegion    string `mapstructure:"vertex_region"`
+	VertexProjectID string `mapstructure:"vertex_project_id"`
+
+	// Description and Tags label a context in listings and the selector.
+	// Unlike the fields above they are not inherited through extends.
+	Description string   `mapstructure:"description"`
+	Tags        []string `mapstructure:"tags"`
+
+	// Env holds extra variables injected into the child process. Names are
+	// upper-cased on load because config keys are case-insensitive.
+	Env map[string]string `mapstructure:"env"`
+	// Unset lists variables removed from the inherited environment.
+	Unset []string `mapstructure:"unset"`
+	// Models maps model aliases such as "opus" to full model IDs. Resolve
+	// merges in the aliases of parents and of the top-level [models] table.
+	Models map[string]string `mapstructure:"models"`
+
+	// Sources maps each non-empty field's config key to the layer it was
+	// taken from, e.g. "context.work" or "defaults". Set by Resolve.
+	Sources map[string]string `mapstructure:"-"`
+	// Refs maps the key of each value resolved from a secret reference to
+	// that reference, e.g. "auth_token" to "env:WORK_TOKEN". Set by GetContext.
+	Refs map[string]string `mapstructure:"-"`
 }
 
 type Config struct {
-	Contexts map[string]Context `mapstructure:"context"`
+	Defaults map[string]interface{} `mapstructure:"defaults"`
+	Contexts map[string]Context     `mapstructure:"context"`
+	// Models holds the model aliases shared by every context.
+	Models map[string]string `mapstructure:"models"`
+
+	// Path is the file the config was read from. vault: references resolve
+	// against the vault next to it.
+	Path string `mapstructure:"-"`
+
+	// explicit records the keys each context set itself, before defaults were
+	// merged in. Contexts without an entry are treated as fully explicit.
+	explicit map[string]map[string]bool
+	// unknown lists the keys in the file that ccctx does not use.
+	unknown []string
 }
 
-func resolveEnvVar(value string) (string, error) {
-	if strings.HasPrefix(value, "env:") {
-		envVar := strings.TrimPrefix(value, "env:")
-		if envVar == "" {
-			return "", fmt.Errorf("environment variable name cannot be empty")
+// stringFields lists the inheritable string fields of Context by config key.
+var stringFields = []struct {
+	key string
+	ptr func(*Context) *string
+}{
+	{"provider", func(c *Context) *string { return &c.Provider }},
+	{"base_url", func(c *Context) *string { return &c.BaseURL }},
+	{"auth_token", func(c *Context) *string { return &c.AuthToken }},
+	{"aws_region", func(c *Context) *string { return &c.AWSRegion }},
+	{"aws_profile", func(c *Context) *string { return &c.AWSProfile }},
+	{"vertex_region", func(c *Context) *string { return &c.VertexRegion }},
+	{"vertex_project_id", func(c *Context) *string { return &c.VertexProjectID }},
+	{"model", func(c *Context) *string { return &c.Model }},
+	{"small_fast_model", func(c *Context) *string { return &c.SmallFastModel }},
+	{"haiku_model", func(c *Context) *string { return &c.HaikuModel }},
+	{"sonnet_model", func(c *Context) *string { return &c.SonnetModel }},
+	{"opus_model", func(c *Context) *string { return &c.OpusModel }},
+}
+
+// Field returns the string field stored under a config key such as
+// "base_url", or "" for keys that are not string fields.
+func (c *Context) Field(key string) string {
+	if key == "description" {
+		return c.Description
+	}
+	for _, f := range stringFields {
+		if f.key == key {
+			return *f.ptr(c)
 		}
+	}
+	return ""
+}
 
-		envValue := os.Getenv(envVar)
-		if envValue == "" {
-			return "", fmt.Errorf("environment variable '%s' is not set or empty", envVar)
+// Resolve returns the named context with every field it leaves empty filled in
+// from its extends chain. Secret references such as env: are left unresolved.
+func (c *Config) Resolve(name string) (*Context, error) {
+	context, exists := c.Contexts[name]
+	if !exists {
+		return nil, fmt.Errorf("context '%s' not found", name)
+	}
+
+	chain := []string{name}
+	resolved := context
+	resolved.Env = maps.Clone(context.Env)
+	resolved.Models = maps.Clone(context.Models)
+	for parentName := context.Extends; parentName != ""; {
+		for i, seen := range chain {
+			if seen == parentName {
+				cycle := strings.Join(append(chain[i:], parentName), " -> ")
+				return nil, fmt.Errorf("context inheritance cycle: %s", cycle)
+			}
+		}
+		parent, exists := c.Contexts[parentName]
+		if !exists {
+			return nil, fmt.Errorf("context '%s' extends unknown context '%s'", chain[len(chain)-1], parentName)
+		}
+		for _, f := range stringFields {
+			if field := f.ptr(&resolved); *field == "" {
+				*field = *f.ptr(&parent)
+			}
 		}
+		for key, value := range parent.Env {
+			if _, exists := resolved.Env[key]; !exists {
+				if resolved.Env == nil {
+					resolved.Env = make(map[string]string, len(parent.Env))
+				}
+				resolved.Env[key] = value
+			}
+		}
+		for alias, model := range parent.Models {
+			if _, exists := resolved.Models[alias]; !exists {
+				if resolved.Models == nil {
+					resolved.Models = make(map[string]string, len(parent.Models))
+				}
+				resolved.Models[alias] = model
+			}
+		}
+		if resolved.Unset == nil {
+			resolved.Unset = parent.Unset
+		}
+		chain = append(chain, parentName)
+		parentName = parent.Extends
+	}
+	resolved.Extends = context.Extends
 
-		return envValue, nil
+	resolved.Sources = make(map[string]string, len(stringFields)+len(resolved.Env))
+	for _, f := range stringFields {
+		if *f.ptr(&resolved) != "" {
+			resolved.Sources[f.key] = c.source(chain, f.key, func(ctx *Context) bool {
+				return *f.ptr(ctx) != ""
+			})
+		}
 	}
-	return value, nil
+	for key := range resolved.Env {
+		resolved.Sources["env."+key] = c.source(chain, "env."+strings.ToLower(key), func(ctx *Context) bool {
+			_, exists := ctx.Env[key]
+			return exists
+		})
+	}
+	if resolved.Unset != nil {
+		resolved.Sources["unset"] = c.source(chain, "unset", func(ctx *Context) bool {
+			return ctx.Unset != nil
+		})
+	}
+	for alias := range resolved.Models {
+		resolved.Sources["models."+alias] = c.source(chain, "models."+alias, func(ctx *Context) bool {
+			_, exists := ctx.Models[alias]
+			return exists
+		})
+	}
+	for alias, model := range c.Models {
+		if _, exists := resolved.Models[alias]; !exists {
+			if resolved.Models == nil {
+				resolved.Models = make(map[string]string, len(c.Models))
+			}
+			resolved.Models[alias] = model
+			resolved.Sources["models."+alias] = "models"
+		}
+	}
+
+	return &resolved, nil
+}
+
+// source returns the layer that supplied key along chain: the first context
+// holding a value for it, or the defaults if that value was merged in.
+func (c *Config) source(chain []string, key string, has func(*Context) bool) string {
+	for _, name := range chain {
+		context := c.Contexts[name]
+		if !has(&context) {
+			continue
+		}
+		if keys, tracked := c.explicit[name]; tracked && !keys[key] {
+			return "defaults"
+		}
+		return "context." + name
+	}
+	return ""
+}
+
+// ExpandModel returns the model ID value stands for: the target of the alias
+// it names, compared case-insensitively, or value itself. alias is the alias
+// that was expanded, or "". Aliases expand once; an alias naming another
+// alias is not followed.
+func (c *Context) ExpandModel(value string) (model, alias string) {
+	if id, exists := c.Models[strings.ToLower(value)]; exists && value != "" {
+		return id, strings.ToLower(value)
+	}
+	return value, ""
+}
+
+func (c *Context) addRef(key, value string) {
+	if !IsReference(value) {
+		return
+	}
+	if c.Refs == nil {
+		c.Refs = make(map[string]string)
+	}
+	c.Refs[key] = value
 }
 
 func GetConfigPath() (string, error) {
@@ -53,12 +244,27 @@ func GetConfigPath() (string, error) {
 	return filepath.Join(home, ".ccctx", "config.toml"), nil
 }
 
+// pathInConfigDir returns the path of name in the directory holding the
+// config file.
+func pathInConfigDir(name string) (string, error) {
+	configPath, err := GetConfigPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(configPath), name), nil
+}
+
 func LoadConfig() (*Config, error) {
 	configPath, err := GetConfigPath()
 	if err != nil {
 		return nil, err
 	}
 
+	cfg, err := defaultLoader.Load(configPath)
+	if !os.IsNotExist(err) {
+		return cfg, err
+	}
+
 	// Create config directory if it doesn't exist
 	dir := filepath.Dir(configPath)
 	if _, err := os.Stat(dir); os.IsNotExist(err) {
@@ -68,8 +274,7 @@ func LoadConfig() (*Config, error) {
 	}
 
 	// Create default config file if it doesn't exist
-	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		defaultConfig := `# Claude-Code Context Configuration
+	defaultConfig := `# Claude-Code Context Configuration
 [context.example]
 base_url = "https://api.anthropic.com"
 auth_token = "your-auth-token-here"
@@ -80,38 +285,116 @@ auth_token = "your-auth-token-here"
 # opus_model = "claude-opus-4-7"
 # small_fast_model = "claude-haiku-4-5-20251001"  # deprecated: use haiku_model instead
 `
-		if err := os.WriteFile(configPath, []byte(defaultConfig), 0600); err != nil {
-			return nil, err
-		}
+	if err := os.WriteFile(configPath, []byte(defaultConfig), 0600); err != nil {
+		return nil, err
 	}
 
-	viper.SetConfigFile(configPath)
-	viper.SetConfigType("toml")
+	return defaultLoader.Load(configPath)
+}
 
-	if err := viper.ReadInConfig(); err != nil {
+// LoadFile reads the config file at path with a viper instance of its own.
+// Unlike LoadConfig it never creates the file and always reads it; use a
+// Loader to reuse configs that have not changed.
+func LoadFile(path string) (*Config, error) {
+	v := viper.New()
+	v.SetConfigFile(path)
+	v.SetConfigType("toml")
+
+	if err := v.ReadInConfig(); err != nil {
+		var decodeErr *toml.DecodeError
+		if errors.As(err, &decodeErr) {
+			row, column := decodeErr.Position()
+			return nil, fmt.Errorf("failed to read config file: line %d, column %d: %w", row, column, decodeErr)
+		}
 		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
 
+	settings := v.AllSettings()
+	if err := readModelTables(path, settings); err != nil {
+		return nil, err
+	}
+	unknown := unknownKeys(settings)
+	explicit, err := applyDefaults(settings)
+	if err != nil {
+		return nil, err
+	}
+
 	var config Config
-	if err := viper.Unmarshal(&config); err != nil {
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		Result:           &config,
+		WeaklyTypedInput: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := decoder.Decode(settings); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
 	}
+	config.Path = path
+	config.explicit = explicit
+	config.unknown = unknown
+
+	for name, context := range config.Contexts {
+		if len(context.Env) == 0 {
+			continue
+		}
+		env := make(map[string]string, len(context.Env))
+		for key, value := range context.Env {
+			env[strings.ToUpper(key)] = value
+		}
+		context.Env = env
+		config.Contexts[name] = context
+	}
 
 	return &config, nil
 }
 
+// readModelTables replaces the models tables in settings with the ones in the
+// file at path, decoded with go-toml. Viper splits keys on dots, which would
+// turn an alias such as "sonnet-4.5" into a nested table.
+func readModelTables(path string, settings map[string]interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	var file map[string]interface{}
+	if err := toml.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	// Viper lower-cases keys, so aliases and context names are matched that way
+	replace := func(dst, src map[string]interface{}) {
+		models, ok := src["models"].(map[string]interface{})
+		if dst == nil || !ok {
+			return
+		}
+		aliases := make(map[string]interface{}, len(models))
+		for alias, model := range models {
+			aliases[strings.ToLower(alias)] = model
+		}
+		dst["models"] = aliases
+	}
+	replace(settings, file)
+	defaults, _ := file["defaults"].(map[string]interface{})
+	settingsDefaults, _ := settings["defaults"].(map[string]interface{})
+	replace(settingsDefaults, defaults)
+	contexts, _ := file["context"].(map[string]interface{})
+	settingsContexts, _ := settings["context"].(map[string]interface{})
+	for name, raw := range contexts {
+		context, _ := raw.(map[string]interface{})
+		table, _ := settingsContexts[strings.ToLower(name)].(map[string]interface{})
+		replace(table, context)
+	}
+	return nil
+}
+
 func ListContexts() ([]string, error) {
 	config, err := LoadConfig()
 	if err != nil {
 		return nil, err
 	}
 
-	var contexts []string
-	for name := range config.Contexts {
-		contexts = append(contexts, name)
-	}
-
-	return contexts, nil
+	return slices.Sorted(maps.Keys(config.Contexts)), nil
 }
 
 func GetContext(name string) (*Context, error) {
@@ -119,27 +402,33 @@ func GetContext(name string) (*Context, error) {
 	if err != nil {
 		return nil, err
 	}
+	return config.Context(name)
+}
 
-	context, exists := config.Contexts[name]
-	if !exists {
-		return nil, fmt.Errorf("context '%s' not found", name)
+// Context returns the named context resolved through its extends chain, with
+// the secret references in its auth token and extra env resolved.
+func (c *Config) Context(name string) (*Context, error) {
+	context, err := c.Resolve(name)
+	if err != nil {
+		return nil, err
 	}
 
-	// Resolve environment variables in auth token
-	resolvedAuthToken, err := resolveEnvVar(context.AuthToken)
+	// Resolve secret references such as env: in auth token and extra env
+	context.addRef("auth_token", context.AuthToken)
+	resolvedAuthToken, err := c.ResolveSecret(context.AuthToken)
 	if err != nil {
-		return nil, fmt.Errorf("failed to resolve auth token for context '%s': %w", name, err)
+		return nil, fmt.Errorf("failed to resolve auth token for context '%s' (%s): %w", name, schemeOf(context.AuthToken), err)
 	}
+	context.AuthToken = resolvedAuthToken
 
-	resolvedContext := Context{
-		BaseURL:        context.BaseURL,
-		AuthToken:      resolvedAuthToken,
-		Model:          context.Model,
-		SmallFastModel: context.SmallFastModel,
-		HaikuModel:     context.HaikuModel,
-		SonnetModel:    context.SonnetModel,
-		OpusModel:      context.OpusModel,
+	for key, value := range context.Env {
+		context.addRef("env."+key, value)
+		resolved, err := c.ResolveSecret(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve env '%s' for context '%s' (%s): %w", key, name, schemeOf(value), err)
+		}
+		context.Env[key] = resolved
 	}
 
-	return &resolvedContext, nil
+	return context, nil
 }
@@ -83,26 +83,26 @@ func TestResolveEnvVar(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := resolveEnvVar(tt.input)
+			got, err := ResolveSecret(tt.input)
 
 			if tt.wantErr {
 				if err == nil {
-					t.Errorf("resolveEnvVar() expected error, got nil")
+					t.Errorf("ResolveSecret() expected error, got nil")
 					return
 				}
 				if tt.errContains != "" && err.Error() != tt.errContains {
-					t.Errorf("resolveEnvVar() error = %v, want error containing %v", err, tt.errContains)
+					t.Errorf("ResolveSecret() error = %v, want error containing %v", err, tt.errContains)
 				}
 				return
 			}
 
 			if err != nil {
-				t.Errorf("resolveEnvVar() unexpected error = %v", err)
+				t.Errorf("ResolveSecret() unexpected error = %v", err)
 				return
 			}
 
 			if got != tt.want {
-				t.Errorf("resolveEnvVar() = %v, want %v", got, tt.want)
+				t.Errorf("ResolveSecret() = %v, want %v", got, tt.want)
 			}
 		})
 	}
@@ -255,3 +255,439 @@ opus_model = "claude-opus-4-7"
 		})
 	}
 }
+
+func TestGetContext_Extends(t *testing.T) {
+	tests := []struct {
+		name       string
+		configTOML string
+		context    string
+		want       Context
+		wantErr    string
+	}{
+		{
+			name: "child inherits unset fields from parent",
+			configTOML: `[context.base]
+base_url = "https://gateway.example.com"
+auth_token = "base-token"
+model = "base-model"
+opus_model = "base-opus"
+
+[context.child]
+extends = "base"
+auth_token = "child-token"
+`,
+			context: "child",
+			want: Context{
+				Extends:   "base",
+				BaseURL:   "https://gateway.example.com",
+				AuthToken: "child-token",
+				Model:     "base-model",
+				OpusModel: "base-opus",
+			},
+		},
+		{
+			name: "multi-level chain resolves nearest value first",
+			configTOML: `[context.root]
+base_url = "https://root.example.com"
+auth_token = "root-token"
+model = "root-model"
+sonnet_model = "root-sonnet"
+
+[context.mid]
+extends = "root"
+model = "mid-model"
+
+[context.leaf]
+extends = "mid"
+auth_token = "leaf-token"
+`,
+			context: "leaf",
+			want: Context{
+				Extends:     "mid",
+				BaseURL:     "https://root.example.com",
+				AuthToken:   "leaf-token",
+				Model:       "mid-model",
+				SonnetModel: "root-sonnet",
+			},
+		},
+		{
+			name: "env reference inherited from parent is resolved",
+			configTOML: `[context.base]
+base_url = "https://gateway.example.com"
+auth_token = "env:CCCTX_TEST_EXTENDS_TOKEN"
+
+[context.child]
+extends = "base"
+`,
+			context: "child",
+			want: Context{
+				Extends:   "base",
+				BaseURL:   "https://gateway.example.com",
+				AuthToken: "inherited-secret",
+				Refs:      map[string]string{"auth_token": "env:CCCTX_TEST_EXTENDS_TOKEN"},
+			},
+		},
+		{
+			name: "self reference is a cycle",
+			configTOML: `[context.loop]
+extends = "loop"
+`,
+			context: "loop",
+			wantErr: "context inheritance cycle: loop -> loop",
+		},
+		{
+			name: "cycle error names only the looping contexts",
+			configTOML: `[context.a]
+extends = "b"
+
+[context.b]
+extends = "c"
+
+[context.c]
+extends = "b"
+`,
+			context: "a",
+			wantErr: "context inheritance cycle: b -> c -> b",
+		},
+		{
+			name: "unknown parent",
+			configTOML: `[context.child]
+extends = "missing"
+`,
+			context: "child",
+			wantErr: "context 'child' extends unknown context 'missing'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			configPath := filepath.Join(tmpDir, "config.toml")
+			err := os.WriteFile(configPath, []byte(tt.configTOML), 0600)
+			require.NoError(t, err)
+			t.Setenv("CCCTX_CONFIG_PATH", configPath)
+			t.Setenv("CCCTX_TEST_EXTENDS_TOKEN", "inherited-secret")
+
+			ctx, err := GetContext(tt.context)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Equal(t, tt.wantErr, err.Error())
+				return
+			}
+			require.NoError(t, err)
+			ctx.Sources = nil
+			assert.Equal(t, tt.want, *ctx)
+		})
+	}
+}
+
+func TestGetContext_Defaults(t *testing.T) {
+	tests := []struct {
+		name        string
+		configTOML  string
+		context     string
+		want        Context
+		wantSources map[string]string
+		wantErr     string
+	}{
+		{
+			name: "defaults fill fields the context leaves unset",
+			configTOML: `[defaults]
+base_url = "https://gateway.example.com"
+model = "default-model"
+haiku_model = "default-haiku"
+
+[context.work]
+auth_token = "work-token"
+model = "work-model"
+`,
+			context: "work",
+			want: Context{
+				BaseURL:    "https://gateway.example.com",
+				AuthToken:  "work-token",
+				Model:      "work-model",
+				HaikuModel: "default-haiku",
+			},
+			wantSources: map[string]string{
+				"base_url":    "defaults",
+				"auth_token":  "context.work",
+				"model":       "context.work",
+				"haiku_model": "defaults",
+			},
+		},
+		{
+			name: "parent values take precedence over defaults",
+			configTOML: `[defaults]
+base_url = "https://default.example.com"
+model = "default-model"
+opus_model = "default-opus"
+
+[context.base]
+base_url = "https://base.example.com"
+auth_token = "base-token"
+
+[context.child]
+extends = "base"
+model = "child-model"
+`,
+			context: "child",
+			want: Context{
+				Extends:   "base",
+				BaseURL:   "https://base.example.com",
+				AuthToken: "base-token",
+				Model:     "child-model",
+				OpusModel: "default-opus",
+			},
+			wantSources: map[string]string{
+				"base_url":   "context.base",
+				"auth_token": "context.base",
+				"model":      "context.child",
+				"opus_model": "defaults",
+			},
+		},
+		{
+			name: "no defaults section",
+			configTOML: `[context.work]
+base_url = "https://api.example.com"
+auth_token = "work-token"
+`,
+			context: "work",
+			want: Context{
+				BaseURL:   "https://api.example.com",
+				AuthToken: "work-token",
+			},
+			wantSources: map[string]string{
+				"base_url":   "context.work",
+				"auth_token": "context.work",
+			},
+		},
+		{
+			name: "defaults cannot extend",
+			configTOML: `[defaults]
+extends = "work"
+
+[context.work]
+base_url = "https://api.example.com"
+auth_token = "work-token"
+`,
+			context: "work",
+			wantErr: "defaults cannot set extends",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			configPath := filepath.Join(tmpDir, "config.toml")
+			err := os.WriteFile(configPath, []byte(tt.configTOML), 0600)
+			require.NoError(t, err)
+			t.Setenv("CCCTX_CONFIG_PATH", configPath)
+
+			ctx, err := GetContext(tt.context)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Equal(t, tt.wantErr, err.Error())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantSources, ctx.Sources)
+			ctx.Sources = nil
+			assert.Equal(t, tt.want, *ctx)
+		})
+	}
+}
+
+func TestGetContext_Env(t *testing.T) {
+	tests := []struct {
+		name        string
+		configTOML  string
+		context     string
+		wantEnv     map[string]string
+		wantUnset   []string
+		wantSources map[string]string
+		wantErr     string
+	}{
+		{
+			name: "env table and unset list are loaded",
+			configTOML: `[context.work]
+base_url = "https://api.example.com"
+auth_token = "work-token"
+unset = ["HTTP_PROXY", "NODE_OPTIONS"]
+
+[context.work.env]
+HTTPS_PROXY = "http://proxy.example.com:3128"
+CLAUDE_CODE_MAX_OUTPUT_TOKENS = 32000
+`,
+			context: "work",
+			wantEnv: map[string]string{
+				"HTTPS_PROXY":                   "http://proxy.example.com:3128",
+				"CLAUDE_CODE_MAX_OUTPUT_TOKENS": "32000",
+			},
+			wantUnset: []string{"HTTP_PROXY", "NODE_OPTIONS"},
+		},
+		{
+			name: "lowercase names are upper-cased",
+			configTOML: `[context.work]
+base_url = "https://api.example.com"
+auth_token = "work-token"
+
+[context.work.env]
+https_proxy = "http://proxy.example.com:3128"
+`,
+			context: "work",
+			wantEnv: map[string]string{"HTTPS_PROXY": "http://proxy.example.com:3128"},
+		},
+		{
+			name: "env references are resolved",
+			configTOML: `[context.work]
+base_url = "https://api.example.com"
+auth_token = "work-token"
+
+[context.work.env]
+ANTHROPIC_CUSTOM_HEADERS = "env:CCCTX_TEST_HEADERS"
+`,
+			context: "work",
+			wantEnv: map[string]string{"ANTHROPIC_CUSTOM_HEADERS": "X-Team: core"},
+		},
+		{
+			name: "unresolvable env reference names the variable",
+			configTOML: `[context.work]
+base_url = "https://api.example.com"
+auth_token = "work-token"
+
+[context.work.env]
+HTTPS_PROXY = "env:CCCTX_TEST_MISSING"
+`,
+			context: "work",
+			wantErr: "failed to resolve env 'HTTPS_PROXY' for context 'work'",
+		},
+		{
+			name: "env merges per variable across extends and defaults",
+			configTOML: `[defaults.env]
+DISABLE_TELEMETRY = "1"
+HTTPS_PROXY = "http://default-proxy:3128"
+
+[context.base]
+base_url = "https://api.example.com"
+auth_token = "base-token"
+unset = ["NODE_OPTIONS"]
+
+[context.base.env]
+HTTPS_PROXY = "http://base-proxy:3128"
+NODE_EXTRA_CA_CERTS = "/etc/base-ca.pem"
+
+[context.child]
+extends = "base"
+
+[context.child.env]
+NODE_EXTRA_CA_CERTS = "/etc/child-ca.pem"
+`,
+			context: "child",
+			wantEnv: map[string]string{
+				"DISABLE_TELEMETRY":   "1",
+				"HTTPS_PROXY":         "http://base-proxy:3128",
+				"NODE_EXTRA_CA_CERTS": "/etc/child-ca.pem",
+			},
+			wantUnset: []string{"NODE_OPTIONS"},
+			wantSources: map[string]string{
+				"base_url":                "context.base",
+				"auth_token":              "context.base",
+				"env.DISABLE_TELEMETRY":   "defaults",
+				"env.HTTPS_PROXY":         "context.base",
+				"env.NODE_EXTRA_CA_CERTS": "context.child",
+				"unset":                   "context.base",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			configPath := filepath.Join(tmpDir, "config.toml")
+			err := os.WriteFile(configPath, []byte(tt.configTOML), 0600)
+			require.NoError(t, err)
+			t.Setenv("CCCTX_CONFIG_PATH", configPath)
+			t.Setenv("CCCTX_TEST_HEADERS", "X-Team: core")
+
+			ctx, err := GetContext(tt.context)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantEnv, ctx.Env)
+			assert.Equal(t, tt.wantUnset, ctx.Unset)
+			if tt.wantSources != nil {
+				assert.Equal(t, tt.wantSources, ctx.Sources)
+			}
+		})
+	}
+}
+
+func TestGetContext_Models(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.toml")
+	configTOML := `[models]
+opus = "claude-opus-4-7"
+sonnet = "claude-sonnet-4-6"
+haiku = "claude-haiku-4-5"
+"sonnet-4.5" = "claude-sonnet-4-5"
+
+[context.base]
+base_url = "https://api.example.com"
+auth_token = "base-token"
+
+[context.base.models]
+sonnet = "base-sonnet"
+fast = "base-haiku"
+
+[context.child]
+extends = "base"
+model = "Opus"
+
+[context.child.models]
+fast = "child-haiku"
+"Haiku-4.5" = "child-haiku-4-5"
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(configTOML), 0600))
+	t.Setenv("CCCTX_CONFIG_PATH", configPath)
+
+	ctx, err := GetContext("child")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"opus":   "claude-opus-4-7",
+		"sonnet": "base-sonnet",
+		"haiku":  "claude-haiku-4-5",
+		"fast":   "child-haiku",
+		// Dotted alias names are not split into nested tables
+		"sonnet-4.5": "claude-sonnet-4-5",
+		"haiku-4.5":  "child-haiku-4-5",
+	}, ctx.Models)
+	assert.Equal(t, "models", ctx.Sources["models.opus"])
+	assert.Equal(t, "context.base", ctx.Sources["models.sonnet"])
+	assert.Equal(t, "context.child", ctx.Sources["models.fast"])
+	assert.Equal(t, "context.child", ctx.Sources["models.haiku-4.5"])
+	assert.Equal(t, "Opus", ctx.Model, "aliases are kept in the fields and expanded by the runner")
+
+	tests := []struct {
+		value     string
+		wantModel string
+		wantAlias string
+	}{
+		{"opus", "claude-opus-4-7", "opus"},
+		{"Opus", "claude-opus-4-7", "opus"},
+		{"fast", "child-haiku", "fast"},
+		{"sonnet-4.5", "claude-sonnet-4-5", "sonnet-4.5"},
+		{"claude-opus-4-7", "claude-opus-4-7", ""},
+		{"", "", ""},
+	}
+	for _, tt := range tests {
+		model, alias := ctx.ExpandModel(tt.value)
+		assert.Equal(t, tt.wantModel, model, tt.value)
+		assert.Equal(t, tt.wantAlias, alias, tt.value)
+	}
+
+	base, err := GetContext("base")
+	require.NoError(t, err)
+	assert.Equal(t, "base-haiku", base.Models["fast"], "a child's aliases do not leak into its parent")
+}
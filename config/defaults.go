@@ -0,0 +1,84 @@
+package config
+
+import "fmt"
+
+// applyDefaults merges the [defaults] table into every context that does not
+// extend another one, so inheriting contexts pick the defaults up through
+// their root and a parent's values still take precedence over the defaults.
+// It returns the keys each context set itself, for source reporting.
+func applyDefaults(settings map[string]interface{}) (map[string]map[string]bool, error) {
+	contexts, _ := settings["context"].(map[string]interface{})
+
+	explicit := make(map[string]map[string]bool, len(contexts))
+	for name, raw := range contexts {
+		table, ok := raw.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("context '%s' must be a table", name)
+		}
+		keys := make(map[string]bool, len(table))
+		collectKeys(keys, "", table)
+		explicit[name] = keys
+	}
+
+	rawDefaults, exists := settings["defaults"]
+	if !exists {
+		return explicit, nil
+	}
+	defaults, ok := rawDefaults.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("defaults must be a table")
+	}
+	if _, ok := defaults["extends"]; ok {
+		return nil, fmt.Errorf("defaults cannot set extends")
+	}
+
+	for _, raw := range contexts {
+		table := raw.(map[string]interface{})
+		if _, ok := table["extends"]; ok {
+			continue
+		}
+		mergeMissing(table, defaults)
+	}
+
+	return explicit, nil
+}
+
+// collectKeys records every key of table, naming nested keys by their dotted
+// path such as "env.https_proxy".
+func collectKeys(keys map[string]bool, prefix string, table map[string]interface{}) {
+	for key, value := range table {
+		keys[prefix+key] = true
+		if nested, ok := value.(map[string]interface{}); ok {
+			collectKeys(keys, prefix+key+".", nested)
+		}
+	}
+}
+
+// mergeMissing copies keys from src that dst does not set, descending into
+// nested tables so a context can override individual keys of a default table.
+func mergeMissing(dst, src map[string]interface{}) {
+	for key, value := range src {
+		existing, exists := dst[key]
+		if !exists {
+			dst[key] = copyValue(value)
+			continue
+		}
+		dstTable, dstOK := existing.(map[string]interface{})
+		srcTable, srcOK := value.(map[string]interface{})
+		if dstOK && srcOK {
+			mergeMissing(dstTable, srcTable)
+		}
+	}
+}
+
+func copyValue(value interface{}) interface{} {
+	table, ok := value.(map[string]interface{})
+	if !ok {
+		return value
+	}
+	copied := make(map[string]interface{}, len(table))
+	for key, v := range table {
+		copied[key] = copyValue(v)
+	}
+	return copied
+}
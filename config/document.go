@@ -0,0 +1,489 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// document is a TOML file held as raw lines so that edits keep comments, blank
+// lines and key order intact. It understands just enough TOML to locate table
+// headers and key/value pairs, including values that span several lines.
+type document struct {
+	lines []string
+}
+
+// entry is a table header or key/value pair found in a document.
+type entry struct {
+	header bool
+	// array marks an array of tables header, [[like.this]].
+	array bool
+	// path is the table path for headers and the full dotted path of the key,
+	// including its table, for key/value pairs.
+	path []string
+	// start and end delimit the entry's lines, end exclusive.
+	start, end int
+	// key is the raw key text of a key/value pair, comment the trailing
+	// comment of a single-line entry.
+	key, comment string
+	value        string
+}
+
+var bareKey = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+func isBareKeyChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
+
+func parseDocument(data []byte) *document {
+	text := strings.TrimRight(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	if text == "" {
+		return &document{}
+	}
+	return &document{lines: strings.Split(text, "\n")}
+}
+
+func (d *document) bytes() []byte {
+	lines := d.lines
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return []byte(strings.Join(lines, "\n") + "\n")
+}
+
+func (d *document) entries() ([]entry, error) {
+	var entries []entry
+	var table []string
+	for i := 0; i < len(d.lines); {
+		line := strings.TrimSpace(d.lines[i])
+		if line == "" || line[0] == '#' {
+			i++
+			continue
+		}
+
+		if line[0] == '[' {
+			open, close := "[", "]"
+			if strings.HasPrefix(line, "[[") {
+				open, close = "[[", "]]"
+			}
+			path, rest, err := parseKey(line[len(open):])
+			if err != nil || !strings.HasPrefix(rest, close) {
+				return nil, fmt.Errorf("line %d: invalid table header", i+1)
+			}
+			table = path
+			entries = append(entries, entry{
+				header:  true,
+				array:   open == "[[",
+				path:    path,
+				start:   i,
+				end:     i + 1,
+				comment: strings.TrimSpace(rest[len(close):]),
+			})
+			i++
+			continue
+		}
+
+		indent := len(d.lines[i]) - len(strings.TrimLeft(d.lines[i], " \t"))
+		raw := d.lines[i][indent:]
+		parts, rest, err := parseKey(raw)
+		if err != nil || !strings.HasPrefix(rest, "=") {
+			return nil, fmt.Errorf("line %d: expected key = value", i+1)
+		}
+		value := strings.TrimSpace(rest[1:])
+		e := entry{
+			path:  append(slices.Clone(table), parts...),
+			start: i,
+			end:   d.valueEnd(i, value),
+			key:   strings.TrimRight(d.lines[i][:len(d.lines[i])-len(rest)], " \t"),
+			value: value,
+		}
+		if e.end == i+1 {
+			var st scanState
+			if at := st.scan(value); at >= 0 {
+				e.value, e.comment = strings.TrimSpace(value[:at]), value[at:]
+			}
+		}
+		entries = append(entries, e)
+		i = e.end
+	}
+	return entries, nil
+}
+
+// scanState tracks open strings and brackets while scanning a value.
+type scanState struct {
+	quote string
+	depth int
+}
+
+// scan advances over text and returns the index of a trailing comment, or -1.
+func (s *scanState) scan(text string) int {
+	for j := 0; j < len(text); j++ {
+		if s.quote != "" {
+			switch {
+			case s.quote[0] == '"' && text[j] == '\\':
+				j++
+			case strings.HasPrefix(text[j:], s.quote):
+				j += len(s.quote) - 1
+				s.quote = ""
+			}
+			continue
+		}
+		switch c := text[j]; {
+		case strings.HasPrefix(text[j:], `"""`), strings.HasPrefix(text[j:], "'''"):
+			s.quote = text[j : j+3]
+			j += 2
+		case c == '"' || c == '\'':
+			s.quote = string(c)
+		case c == '[' || c == '{':
+			s.depth++
+		case c == ']' || c == '}':
+			s.depth--
+		case c == '#':
+			return j
+		}
+	}
+	// Only multi-line strings continue past the end of a line
+	if len(s.quote) == 1 {
+		s.quote = ""
+	}
+	return -1
+}
+
+// valueEnd returns the line after the value starting on line i.
+func (d *document) valueEnd(i int, value string) int {
+	var st scanState
+	st.scan(value)
+	for st.quote != "" || st.depth > 0 {
+		i++
+		if i >= len(d.lines) {
+			break
+		}
+		st.scan(d.lines[i])
+	}
+	return min(i+1, len(d.lines))
+}
+
+// parseKey reads a possibly dotted and quoted key from the start of s and
+// returns its parts and the rest of s after any whitespace.
+func parseKey(s string) ([]string, string, error) {
+	var parts []string
+	for {
+		s = strings.TrimLeft(s, " \t")
+		var part string
+		switch {
+		case strings.HasPrefix(s, `"`):
+			end := 1
+			for end < len(s) && s[end] != '"' {
+				if s[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(s) {
+				return nil, "", fmt.Errorf("unterminated quoted key")
+			}
+			unquoted, err := strconv.Unquote(s[:end+1])
+			if err != nil {
+				return nil, "", err
+			}
+			part, s = unquoted, s[end+1:]
+		case strings.HasPrefix(s, "'"):
+			end := strings.IndexByte(s[1:], '\'')
+			if end < 0 {
+				return nil, "", fmt.Errorf("unterminated quoted key")
+			}
+			part, s = s[1:end+1], s[end+2:]
+		default:
+			n := 0
+			for n < len(s) && isBareKeyChar(s[n]) {
+				n++
+			}
+			if n == 0 {
+				return nil, "", fmt.Errorf("invalid key")
+			}
+			part, s = s[:n], s[n:]
+		}
+		parts = append(parts, part)
+		s = strings.TrimLeft(s, " \t")
+		if !strings.HasPrefix(s, ".") {
+			return parts, s, nil
+		}
+		s = s[1:]
+	}
+}
+
+// formatPath renders a dotted key, quoting parts that are not bare keys.
+func formatPath(path []string) string {
+	parts := make([]string, len(path))
+	for i, part := range path {
+		if bareKey.MatchString(part) {
+			parts[i] = part
+		} else {
+			parts[i] = quoteString(part)
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+// quoteString renders s as a TOML basic string.
+func quoteString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch {
+		case r == '"' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r < 0x20 || r == 0x7f:
+			fmt.Fprintf(&b, `\u%04X`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// stringValue returns the contents of a single-line TOML string value.
+func stringValue(value string) (string, bool) {
+	switch {
+	case len(value) >= 2 && strings.HasPrefix(value, `"`) && !strings.HasPrefix(value, `"""`):
+		s, err := strconv.Unquote(value)
+		return s, err == nil
+	case len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'' && !strings.HasPrefix(value, "'''"):
+		return value[1 : len(value)-1], true
+	}
+	return "", false
+}
+
+// pathHasPrefix reports whether path starts with prefix. Keys are compared
+// case-insensitively, as the config loader does.
+func pathHasPrefix(path, prefix []string) bool {
+	if len(path) < len(prefix) {
+		return false
+	}
+	for i := range prefix {
+		if !strings.EqualFold(path[i], prefix[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func pathEqual(a, b []string) bool {
+	return len(a) == len(b) && pathHasPrefix(a, b)
+}
+
+func (d *document) insert(at int, lines ...string) {
+	d.lines = slices.Insert(d.lines, at, lines...)
+}
+
+func (d *document) delete(start, end int) {
+	d.lines = slices.Delete(d.lines, start, end)
+}
+
+// tableBlock returns the lines belonging to the table whose header is
+// entries[i]: the header, its keys, and comment lines directly above the
+// header unless they open the file. Comments directly above the next header
+// belong to that header instead.
+func (d *document) tableBlock(entries []entry, i int) (start, end int) {
+	start = entries[i].start
+	for start > 0 && strings.HasPrefix(strings.TrimSpace(d.lines[start-1]), "#") {
+		start--
+	}
+	if start == 0 && entries[i].start > 0 {
+		start = entries[i].start
+	}
+
+	end = len(d.lines)
+	for _, e := range entries[i+1:] {
+		if e.header {
+			end = e.start
+			for end > tableEnd(entries, i) && strings.HasPrefix(strings.TrimSpace(d.lines[end-1]), "#") {
+				end--
+			}
+			break
+		}
+	}
+	return start, end
+}
+
+// tableEnd returns the line after the last key of the table whose header is
+// entries[i].
+func tableEnd(entries []entry, i int) int {
+	end := entries[i].end
+	for _, e := range entries[i+1:] {
+		if e.header {
+			break
+		}
+		end = e.end
+	}
+	return end
+}
+
+// set assigns a string value to the key at path, replacing an existing value
+// in place or adding the key to the deepest existing table along path.
+func (d *document) set(path []string, value string) error {
+	entries, err := d.entries()
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if e.header {
+			continue
+		}
+		if pathEqual(e.path, path) {
+			line := e.key + " = " + quoteString(value)
+			if e.comment != "" {
+				line += " " + e.comment
+			}
+			d.delete(e.start, e.end)
+			d.insert(e.start, line)
+			return nil
+		}
+		if pathHasPrefix(path, e.path) {
+			return fmt.Errorf("'%s' is an inline value; edit it in the config file directly", formatPath(e.path))
+		}
+	}
+
+	for depth := len(path) - 1; depth > 0; depth-- {
+		for i, e := range entries {
+			if !e.header || !pathEqual(e.path, path[:depth]) {
+				continue
+			}
+			at := tableEnd(entries, i)
+			if depth == len(path)-1 {
+				d.insert(at, formatPath(path[depth:])+" = "+quoteString(value))
+			} else {
+				// Nested keys go into their own table after this one
+				table := path[:len(path)-1]
+				d.insert(at, "", "["+formatPath(table)+"]", formatPath(path[len(path)-1:])+" = "+quoteString(value))
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("no table for '%s'", formatPath(path))
+}
+
+// unset removes the key at path, along with its table if that leaves a
+// sub-table empty. It reports whether the key was present.
+func (d *document) unset(path []string) (bool, error) {
+	entries, err := d.entries()
+	if err != nil {
+		return false, err
+	}
+	for i, e := range entries {
+		if e.header || !pathEqual(e.path, path) {
+			continue
+		}
+		// Drop a sub-table such as [context.work.env] once its last key goes
+		onlyKey := i > 0 && entries[i-1].header && (i+1 == len(entries) || entries[i+1].header)
+		if onlyKey && len(entries[i-1].path) > 2 {
+			start, end := d.tableBlock(entries, i-1)
+			d.delete(start, end)
+			d.trimBlank(start)
+			return true, nil
+		}
+		d.delete(e.start, e.end)
+		return true, nil
+	}
+	return false, nil
+}
+
+// trimBlank collapses a run of blank lines left around line i by a deletion.
+func (d *document) trimBlank(i int) {
+	for i > 0 && i <= len(d.lines) && strings.TrimSpace(d.lines[i-1]) == "" &&
+		(i == len(d.lines) || strings.TrimSpace(d.lines[i]) == "") {
+		d.delete(i-1, i)
+		i--
+	}
+}
+
+// tables returns the indexes of headers at or below prefix.
+func tables(entries []entry, prefix []string) []int {
+	var indexes []int
+	for i, e := range entries {
+		if e.header && pathHasPrefix(e.path, prefix) {
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes
+}
+
+// removeTables deletes every table at or below prefix.
+func (d *document) removeTables(prefix []string) error {
+	entries, err := d.entries()
+	if err != nil {
+		return err
+	}
+	indexes := tables(entries, prefix)
+	if len(indexes) == 0 {
+		return fmt.Errorf("no [%s] table", formatPath(prefix))
+	}
+	for _, i := range slices.Backward(indexes) {
+		start, end := d.tableBlock(entries, i)
+		d.delete(start, end)
+		d.trimBlank(start)
+	}
+	return nil
+}
+
+// renameTables moves every table at or below from to the same place below to.
+func (d *document) renameTables(from, to []string) error {
+	entries, err := d.entries()
+	if err != nil {
+		return err
+	}
+	indexes := tables(entries, from)
+	if len(indexes) == 0 {
+		return fmt.Errorf("no [%s] table", formatPath(from))
+	}
+	for _, i := range indexes {
+		d.lines[entries[i].start] = header(entries[i], from, to)
+	}
+	return nil
+}
+
+// copyTables appends a copy of every table at or below from, moved below to.
+func (d *document) copyTables(from, to []string) error {
+	entries, err := d.entries()
+	if err != nil {
+		return err
+	}
+	indexes := tables(entries, from)
+	if len(indexes) == 0 {
+		return fmt.Errorf("no [%s] table", formatPath(from))
+	}
+	var lines []string
+	for _, i := range indexes {
+		_, end := d.tableBlock(entries, i)
+		for end > entries[i].end && strings.TrimSpace(d.lines[end-1]) == "" {
+			end--
+		}
+		lines = append(lines, "", header(entries[i], from, to))
+		lines = append(lines, d.lines[entries[i].end:end]...)
+	}
+	d.insert(len(d.lines), lines...)
+	return nil
+}
+
+// header renders the header of table e with its from prefix replaced by to.
+func header(e entry, from, to []string) string {
+	path := append(slices.Clone(to), e.path[len(from):]...)
+	line := "[" + formatPath(path) + "]"
+	if e.array {
+		line = "[" + line + "]"
+	}
+	if e.comment != "" {
+		line += " " + e.comment
+	}
+	return line
+}
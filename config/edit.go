@@ -0,0 +1,269 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/dsdashun/ccctx/internal/fsutil"
+	"github.com/spf13/viper"
+)
+
+// Context names are restricted to what viper keeps intact: it lowercases keys
+// and splits them on dots.
+var contextName = regexp.MustCompile(`^[a-z0-9_-]+$`)
+
+var envName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+func validateContextName(name string) error {
+	if !contextName.MatchString(name) {
+		return fmt.Errorf("invalid context name '%s': use lowercase letters, digits, '-' and '_'", name)
+	}
+	return nil
+}
+
+// FieldKeys returns the keys accepted by SetField, in config file order.
+func FieldKeys() []string {
+	keys := []string{"extends"}
+	for _, f := range stringFields {
+		keys = append(keys, f.key)
+	}
+	return append(keys, "description", "env.<NAME>")
+}
+
+// fieldPath validates a field key such as "base_url" or "env.HTTPS_PROXY" and
+// returns its path below the context table.
+func fieldPath(key string) ([]string, error) {
+	if name, ok := strings.CutPrefix(key, "env."); ok {
+		if !envName.MatchString(name) {
+			return nil, fmt.Errorf("invalid environment variable name '%s'", name)
+		}
+		return []string{"env", name}, nil
+	}
+	if slices.Contains(FieldKeys(), key) {
+		return []string{key}, nil
+	}
+	return nil, fmt.Errorf("unknown field '%s'; expected one of %s", key, strings.Join(FieldKeys(), ", "))
+}
+
+func contextPath(name string, field ...string) []string {
+	return append([]string{"context", name}, field...)
+}
+
+// editConfig applies edit to the config file and writes the result back
+// atomically, readable by the owner only. Comments and ordering are kept, and
+// nothing is written unless the edited file still parses.
+func editConfig(edit func(cfg *Config, doc *document) error) error {
+	cfg, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+	configPath, err := GetConfigPath()
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return err
+	}
+
+	doc := parseDocument(data)
+	if err := edit(cfg, doc); err != nil {
+		return err
+	}
+
+	out := doc.bytes()
+	check := viper.New()
+	check.SetConfigType("toml")
+	if err := check.ReadConfig(bytes.NewReader(out)); err != nil {
+		return fmt.Errorf("refusing to write an invalid config file: %w", err)
+	}
+	defer defaultLoader.Forget(configPath)
+	return fsutil.WriteFileAtomic(configPath, out, 0600)
+}
+
+func requireContext(cfg *Config, name string) error {
+	if _, exists := cfg.Contexts[name]; !exists {
+		return fmt.Errorf("context '%s' not found", name)
+	}
+	return nil
+}
+
+func requireNewContext(cfg *Config, name string) error {
+	if err := validateContextName(name); err != nil {
+		return err
+	}
+	if _, exists := cfg.Contexts[name]; exists {
+		return fmt.Errorf("context '%s' already exists", name)
+	}
+	return nil
+}
+
+// checkField validates a field assignment against the loaded config.
+func checkField(cfg *Config, name, key, value string) ([]string, error) {
+	path, err := fieldPath(key)
+	if err != nil {
+		return nil, err
+	}
+	if key == "provider" {
+		if _, err := RequiredFields(value); err != nil {
+			return nil, err
+		}
+	}
+	if key == "extends" {
+		if value == name {
+			return nil, fmt.Errorf("context '%s' cannot extend itself", name)
+		}
+		if err := requireContext(cfg, value); err != nil {
+			return nil, err
+		}
+		for parent, hops := value, 0; parent != "" && hops <= len(cfg.Contexts); hops++ {
+			if parent == name {
+				return nil, fmt.Errorf("context '%s' cannot extend '%s': '%s' already inherits from '%s'", name, value, value, name)
+			}
+			parent = cfg.Contexts[parent].Extends
+		}
+	}
+	return path, nil
+}
+
+// AddContext appends a new [context.<name>] table holding fields, which are
+// keyed as for SetField.
+func AddContext(name string, fields map[string]string) error {
+	return editConfig(func(cfg *Config, doc *document) error {
+		if err := requireNewContext(cfg, name); err != nil {
+			return err
+		}
+		keys := slices.Sorted(maps.Keys(fields))
+		// Write fields in the order the config file documents them
+		order := FieldKeys()
+		slices.SortStableFunc(keys, func(a, b string) int {
+			return fieldRank(order, a) - fieldRank(order, b)
+		})
+
+		doc.insert(len(doc.lines), "", "["+formatPath(contextPath(name))+"]")
+		for _, key := range keys {
+			path, err := checkField(cfg, name, key, fields[key])
+			if err != nil {
+				return err
+			}
+			if err := doc.set(contextPath(name, path...), fields[key]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func fieldRank(order []string, key string) int {
+	if strings.HasPrefix(key, "env.") {
+		return len(order)
+	}
+	return slices.Index(order, key)
+}
+
+// RemoveContext deletes a context's tables from the config file and forgets it
+// in the state file. Contexts that other contexts extend cannot be removed.
+func RemoveContext(name string) error {
+	err := editConfig(func(cfg *Config, doc *document) error {
+		if err := requireContext(cfg, name); err != nil {
+			return err
+		}
+		var children []string
+		for child, context := range cfg.Contexts {
+			if context.Extends == name {
+				children = append(children, child)
+			}
+		}
+		if len(children) > 0 {
+			slices.Sort(children)
+			return fmt.Errorf("context '%s' is extended by %s", name, strings.Join(children, ", "))
+		}
+		return doc.removeTables(contextPath(name))
+	})
+	if err != nil {
+		return err
+	}
+	return renameInState(name, "")
+}
+
+// RenameContext renames a context, updating the extends keys that refer to it
+// and the state file.
+func RenameContext(oldName, newName string) error {
+	err := editConfig(func(cfg *Config, doc *document) error {
+		if err := requireContext(cfg, oldName); err != nil {
+			return err
+		}
+		if err := requireNewContext(cfg, newName); err != nil {
+			return err
+		}
+		if err := doc.renameTables(contextPath(oldName), contextPath(newName)); err != nil {
+			return err
+		}
+		for child, context := range cfg.Contexts {
+			if context.Extends == oldName {
+				if err := doc.set(contextPath(child, "extends"), newName); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return renameInState(oldName, newName)
+}
+
+// CopyContext appends a copy of a context's tables under a new name.
+func CopyContext(src, dst string) error {
+	return editConfig(func(cfg *Config, doc *document) error {
+		if err := requireContext(cfg, src); err != nil {
+			return err
+		}
+		if err := requireNewContext(cfg, dst); err != nil {
+			return err
+		}
+		return doc.copyTables(contextPath(src), contextPath(dst))
+	})
+}
+
+// SetField sets a field of a context, such as "model" or "env.HTTPS_PROXY",
+// replacing its value in place when the context already sets it.
+func SetField(name, key, value string) error {
+	return editConfig(func(cfg *Config, doc *document) error {
+		if err := requireContext(cfg, name); err != nil {
+			return err
+		}
+		path, err := checkField(cfg, name, key, value)
+		if err != nil {
+			return err
+		}
+		return doc.set(contextPath(name, path...), value)
+	})
+}
+
+// UnsetField removes a field set by a context itself.
+func UnsetField(name, key string) error {
+	return editConfig(func(cfg *Config, doc *document) error {
+		if err := requireContext(cfg, name); err != nil {
+			return err
+		}
+		path, err := fieldPath(key)
+		if err != nil {
+			return err
+		}
+		removed, err := doc.unset(contextPath(name, path...))
+		if err != nil {
+			return err
+		}
+		if !removed {
+			return fmt.Errorf("context '%s' does not set '%s'", name, key)
+		}
+		return nil
+	})
+}
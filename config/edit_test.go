@@ -0,0 +1,358 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const editBaseConfig = `# Claude-Code Context Configuration
+
+# Main company gateway
+[context.work]
+base_url = "https://work.example.com" # proxied
+auth_token = "env:WORK_TOKEN"
+model = "work-model"
+
+[context.work.env]
+HTTPS_PROXY = "http://proxy:3128"
+
+# Staging, shares everything but the token
+[context.staging]
+extends = "work"
+auth_token = 'env:STAGING_TOKEN'
+unset = [
+  "HTTP_PROXY",
+  "NO_PROXY",
+]
+`
+
+func TestEditConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		edit    func() error
+		want    string
+		wantErr string
+	}{
+		{
+			name: "set replaces a value in place and keeps its comment",
+			edit: func() error { return SetField("work", "base_url", "https://new.example.com") },
+			want: `# Claude-Code Context Configuration
+
+# Main company gateway
+[context.work]
+base_url = "https://new.example.com" # proxied
+auth_token = "env:WORK_TOKEN"
+model = "work-model"
+
+[context.work.env]
+HTTPS_PROXY = "http://proxy:3128"
+
+# Staging, shares everything but the token
+[context.staging]
+extends = "work"
+auth_token = 'env:STAGING_TOKEN'
+unset = [
+  "HTTP_PROXY",
+  "NO_PROXY",
+]
+`,
+		},
+		{
+			name: "set adds a new field after the context's last key",
+			edit: func() error { return SetField("staging", "opus_model", "opus \"x\"") },
+			want: `# Claude-Code Context Configuration
+
+# Main company gateway
+[context.work]
+base_url = "https://work.example.com" # proxied
+auth_token = "env:WORK_TOKEN"
+model = "work-model"
+
+[context.work.env]
+HTTPS_PROXY = "http://proxy:3128"
+
+# Staging, shares everything but the token
+[context.staging]
+extends = "work"
+auth_token = 'env:STAGING_TOKEN'
+unset = [
+  "HTTP_PROXY",
+  "NO_PROXY",
+]
+opus_model = "opus \"x\""
+`,
+		},
+		{
+			name: "set env adds to the existing env table",
+			edit: func() error { return SetField("work", "env.NO_PROXY", "localhost") },
+			want: `# Claude-Code Context Configuration
+
+# Main company gateway
+[context.work]
+base_url = "https://work.example.com" # proxied
+auth_token = "env:WORK_TOKEN"
+model = "work-model"
+
+[context.work.env]
+HTTPS_PROXY = "http://proxy:3128"
+NO_PROXY = "localhost"
+
+# Staging, shares everything but the token
+[context.staging]
+extends = "work"
+auth_token = 'env:STAGING_TOKEN'
+unset = [
+  "HTTP_PROXY",
+  "NO_PROXY",
+]
+`,
+		},
+		{
+			name: "set env creates the env table",
+			edit: func() error { return SetField("staging", "env.DISABLE_TELEMETRY", "1") },
+			want: `# Claude-Code Context Configuration
+
+# Main company gateway
+[context.work]
+base_url = "https://work.example.com" # proxied
+auth_token = "env:WORK_TOKEN"
+model = "work-model"
+
+[context.work.env]
+HTTPS_PROXY = "http://proxy:3128"
+
+# Staging, shares everything but the token
+[context.staging]
+extends = "work"
+auth_token = 'env:STAGING_TOKEN'
+unset = [
+  "HTTP_PROXY",
+  "NO_PROXY",
+]
+
+[context.staging.env]
+DISABLE_TELEMETRY = "1"
+`,
+		},
+		{
+			name: "unset removes a key",
+			edit: func() error { return UnsetField("work", "model") },
+			want: `# Claude-Code Context Configuration
+
+# Main company gateway
+[context.work]
+base_url = "https://work.example.com" # proxied
+auth_token = "env:WORK_TOKEN"
+
+[context.work.env]
+HTTPS_PROXY = "http://proxy:3128"
+
+# Staging, shares everything but the token
+[context.staging]
+extends = "work"
+auth_token = 'env:STAGING_TOKEN'
+unset = [
+  "HTTP_PROXY",
+  "NO_PROXY",
+]
+`,
+		},
+		{
+			name: "unset of the last env var drops the env table",
+			edit: func() error { return UnsetField("work", "env.https_proxy") },
+			want: `# Claude-Code Context Configuration
+
+# Main company gateway
+[context.work]
+base_url = "https://work.example.com" # proxied
+auth_token = "env:WORK_TOKEN"
+model = "work-model"
+
+# Staging, shares everything but the token
+[context.staging]
+extends = "work"
+auth_token = 'env:STAGING_TOKEN'
+unset = [
+  "HTTP_PROXY",
+  "NO_PROXY",
+]
+`,
+		},
+		{
+			name: "add appends a table in field order",
+			edit: func() error {
+				return AddContext("personal", map[string]string{
+					"model":           "claude-sonnet-4-6",
+					"auth_token":      "vault:personal",
+					"base_url":        "https://api.anthropic.com",
+					"env.API_TIMEOUT": "600",
+				})
+			},
+			want: editBaseConfig + `
+[context.personal]
+base_url = "https://api.anthropic.com"
+auth_token = "vault:personal"
+model = "claude-sonnet-4-6"
+
+[context.personal.env]
+API_TIMEOUT = "600"
+`,
+		},
+		{
+			name: "remove drops the tables and their leading comment",
+			edit: func() error { return RemoveContext("staging") },
+			want: `# Claude-Code Context Configuration
+
+# Main company gateway
+[context.work]
+base_url = "https://work.example.com" # proxied
+auth_token = "env:WORK_TOKEN"
+model = "work-model"
+
+[context.work.env]
+HTTPS_PROXY = "http://proxy:3128"
+`,
+		},
+		{
+			name: "rename updates headers and extends references",
+			edit: func() error { return RenameContext("work", "corp") },
+			want: `# Claude-Code Context Configuration
+
+# Main company gateway
+[context.corp]
+base_url = "https://work.example.com" # proxied
+auth_token = "env:WORK_TOKEN"
+model = "work-model"
+
+[context.corp.env]
+HTTPS_PROXY = "http://proxy:3128"
+
+# Staging, shares everything but the token
+[context.staging]
+extends = "corp"
+auth_token = 'env:STAGING_TOKEN'
+unset = [
+  "HTTP_PROXY",
+  "NO_PROXY",
+]
+`,
+		},
+		{
+			name: "copy appends the tables under the new name",
+			edit: func() error { return CopyContext("work", "work-eu") },
+			want: editBaseConfig + `
+[context.work-eu]
+base_url = "https://work.example.com" # proxied
+auth_token = "env:WORK_TOKEN"
+model = "work-model"
+
+[context.work-eu.env]
+HTTPS_PROXY = "http://proxy:3128"
+`,
+		},
+		{
+			name:    "remove a context that others extend",
+			edit:    func() error { return RemoveContext("work") },
+			wantErr: "context 'work' is extended by staging",
+		},
+		{
+			name:    "set an unknown field",
+			edit:    func() error { return SetField("work", "base_ur", "x") },
+			wantErr: "unknown field 'base_ur'",
+		},
+		{
+			name:    "set on a missing context",
+			edit:    func() error { return SetField("missing", "model", "x") },
+			wantErr: "context 'missing' not found",
+		},
+		{
+			name:    "extends creating a cycle",
+			edit:    func() error { return SetField("work", "extends", "staging") },
+			wantErr: "'staging' already inherits from 'work'",
+		},
+		{
+			name:    "extends an unknown context",
+			edit:    func() error { return SetField("work", "extends", "missing") },
+			wantErr: "context 'missing' not found",
+		},
+		{
+			name:    "unset a field the context does not set",
+			edit:    func() error { return UnsetField("staging", "model") },
+			wantErr: "context 'staging' does not set 'model'",
+		},
+		{
+			name:    "add an existing context",
+			edit:    func() error { return AddContext("work", nil) },
+			wantErr: "context 'work' already exists",
+		},
+		{
+			name:    "add with an invalid name",
+			edit:    func() error { return AddContext("my.ctx", nil) },
+			wantErr: "invalid context name 'my.ctx'",
+		},
+		{
+			name:    "rename onto an existing context",
+			edit:    func() error { return RenameContext("staging", "work") },
+			wantErr: "context 'work' already exists",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "config.toml")
+			require.NoError(t, os.WriteFile(configPath, []byte(editBaseConfig), 0644))
+			t.Setenv("CCCTX_CONFIG_PATH", configPath)
+
+			err := tt.edit()
+			data, readErr := os.ReadFile(configPath)
+			require.NoError(t, readErr)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				assert.Equal(t, editBaseConfig, string(data), "failed edits must not touch the file")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, string(data))
+
+			info, err := os.Stat(configPath)
+			require.NoError(t, err)
+			assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+			// The result must load like any hand-written config
+			config, err := LoadConfig()
+			require.NoError(t, err)
+			for name := range config.Contexts {
+				_, err := config.Resolve(name)
+				assert.NoError(t, err, name)
+			}
+		})
+	}
+}
+
+func TestEditConfig_State(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.toml")
+	configTOML := "[context.work]\nbase_url = \"https://work.example.com\"\n\n[context.personal]\nbase_url = \"https://personal.example.com\"\n"
+	require.NoError(t, os.WriteFile(configPath, []byte(configTOML), 0600))
+	t.Setenv("CCCTX_CONFIG_PATH", configPath)
+
+	_, err := UseContext("work")
+	require.NoError(t, err)
+	_, err = UseContext("personal")
+	require.NoError(t, err)
+
+	require.NoError(t, RenameContext("work", "corp"))
+	state, err := LoadState()
+	require.NoError(t, err)
+	assert.Equal(t, State{Current: "personal", Previous: "corp"}, *state)
+
+	require.NoError(t, RemoveContext("personal"))
+	state, err = LoadState()
+	require.NoError(t, err)
+	assert.Equal(t, State{Previous: "corp"}, *state)
+}
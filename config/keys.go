@@ -0,0 +1,52 @@
+package config
+
+import "slices"
+
+// ContextKeys lists the keys a context table may hold. Keys below env and
+// models are names of the user's choosing and are not checked.
+func ContextKeys() []string {
+	keys := []string{"extends"}
+	for _, f := range stringFields {
+		keys = append(keys, f.key)
+	}
+	return append(keys, "description", "tags", "env", "unset", "models")
+}
+
+// UnknownKeys returns the keys in the config file that ccctx does not use,
+// such as a misspelled "context.work.base_ur", sorted.
+func (c *Config) UnknownKeys() []string {
+	return slices.Clone(c.unknown)
+}
+
+// unknownKeys collects the dotted paths of unused keys in settings as read
+// from the file, before defaults are merged into the contexts.
+func unknownKeys(settings map[string]interface{}) []string {
+	var unknown []string
+	checkTable := func(prefix string, table map[string]interface{}, allowed []string) {
+		for key := range table {
+			if !slices.Contains(allowed, key) {
+				unknown = append(unknown, prefix+key)
+			}
+		}
+	}
+	for key, value := range settings {
+		switch key {
+		case "defaults":
+			if table, ok := value.(map[string]interface{}); ok {
+				checkTable("defaults.", table, slices.DeleteFunc(ContextKeys(), func(k string) bool { return k == "extends" }))
+			}
+		case "models":
+		case "context":
+			contexts, _ := value.(map[string]interface{})
+			for name, raw := range contexts {
+				if table, ok := raw.(map[string]interface{}); ok {
+					checkTable("context."+name+".", table, ContextKeys())
+				}
+			}
+		default:
+			unknown = append(unknown, key)
+		}
+	}
+	slices.Sort(unknown)
+	return unknown
+}
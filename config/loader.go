@@ -0,0 +1,72 @@
+package config
+
+import (
+	"os"
+	"sync"
+	"time"
+)
+
+// Loader reads config files and keeps each one it parsed until the file
+// changes on disk, so repeated lookups within a process read it once. It is
+// safe for concurrent use. The configs it returns are shared between callers
+// and must not be modified.
+type Loader struct {
+	mu    sync.Mutex
+	files map[string]*loadedFile
+}
+
+// loadedFile is a parsed config file and the file info it was read with.
+type loadedFile struct {
+	info   os.FileInfo
+	config *Config
+}
+
+// defaultLoader serves LoadConfig, GetContext and ListContexts.
+var defaultLoader = NewLoader()
+
+// NewLoader returns a Loader with nothing cached.
+func NewLoader() *Loader {
+	return &Loader{files: map[string]*loadedFile{}}
+}
+
+// Load returns the config file at path, reading it again only when it has
+// been replaced or its size or modification time changed since the last load.
+func (l *Loader) Load(path string) (*Config, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if cached, ok := l.files[path]; ok && unchanged(cached.info, info) {
+		return cached.config, nil
+	}
+	cfg, err := LoadFile(path)
+	if err != nil {
+		delete(l.files, path)
+		return nil, err
+	}
+	l.files[path] = &loadedFile{info: info, config: cfg}
+	return cfg, nil
+}
+
+// Forget drops the cached copy of path, so the next Load reads it again even
+// if it was rewritten within the file system's timestamp resolution.
+func (l *Loader) Forget(path string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.files, path)
+}
+
+// racyWindow is how recently a file may have been modified for its cached
+// copy to be distrusted: a write in the same clock tick as the read that
+// cached it would leave the size and time unchanged.
+const racyWindow = 2 * time.Second
+
+func unchanged(old, cur os.FileInfo) bool {
+	if !os.SameFile(old, cur) || old.Size() != cur.Size() || !old.ModTime().Equal(cur.ModTime()) {
+		return false
+	}
+	return time.Since(cur.ModTime()) > racyWindow
+}
@@ -0,0 +1,102 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeAged writes a config file with a modification time old enough for
+// Loader to trust its cached copy.
+func writeAged(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	old := time.Now().Add(-time.Minute)
+	require.NoError(t, os.Chtimes(path, old, old))
+}
+
+func TestLoader_Caches(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	writeAged(t, path, "[context.aaaa]\nbase_url = \"https://a.example.com\"\n")
+	l := NewLoader()
+
+	first, err := l.Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, path, first.Path)
+	assert.Contains(t, first.Contexts, "aaaa")
+
+	// Same size and time: the cached copy is used without reading the file
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, []byte("[context.bbbb]\nbase_url = \"https://b.example.com\"\n"), 0600))
+	require.NoError(t, os.Chtimes(path, info.ModTime(), info.ModTime()))
+	second, err := l.Load(path)
+	require.NoError(t, err)
+	assert.Same(t, first, second)
+
+	l.Forget(path)
+	third, err := l.Load(path)
+	require.NoError(t, err)
+	assert.Contains(t, third.Contexts, "bbbb")
+
+	writeAged(t, path, "[context.cccc]\nbase_url = \"https://c.example.com\"\n")
+	fourth, err := l.Load(path)
+	require.NoError(t, err)
+	assert.Contains(t, fourth.Contexts, "cccc", "a changed modification time is noticed")
+}
+
+func TestLoader_RecentlyModified(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, os.WriteFile(path, []byte("[context.work]\n"), 0600))
+	l := NewLoader()
+
+	first, err := l.Load(path)
+	require.NoError(t, err)
+	second, err := l.Load(path)
+	require.NoError(t, err)
+	assert.NotSame(t, first, second, "a file modified just now may change again unnoticed")
+}
+
+func TestLoader_Errors(t *testing.T) {
+	dir := t.TempDir()
+	l := NewLoader()
+
+	_, err := l.Load(filepath.Join(dir, "missing.toml"))
+	assert.True(t, os.IsNotExist(err))
+
+	path := filepath.Join(dir, "config.toml")
+	writeAged(t, path, "[context.work\n")
+	_, err = l.Load(path)
+	assert.ErrorContains(t, err, "failed to read config file")
+}
+
+func TestLoader_Concurrent(t *testing.T) {
+	dir := t.TempDir()
+	l := NewLoader()
+	paths := make([]string, 4)
+	for i := range paths {
+		paths[i] = filepath.Join(dir, fmt.Sprintf("config-%d.toml", i))
+		writeAged(t, paths[i], fmt.Sprintf("[context.ctx-%d]\nbase_url = \"https://%d.example.com\"\n", i, i))
+	}
+
+	var wg sync.WaitGroup
+	for range 8 {
+		for i, path := range paths {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				cfg, err := l.Load(path)
+				if assert.NoError(t, err) {
+					assert.Equal(t, fmt.Sprintf("https://%d.example.com", i), cfg.Contexts[fmt.Sprintf("ctx-%d", i)].BaseURL)
+				}
+			}()
+		}
+	}
+	wg.Wait()
+}
@@ -0,0 +1,97 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// projectFiles are the per-project binding files, in lookup order within a
+// directory: a TOML file that may also override models, or a plain file that
+// holds just the context name.
+var projectFiles = []string{".ccctx.toml", ".ccctx"}
+
+// Project is a per-project context binding.
+type Project struct {
+	// Path is the file the binding was read from.
+	Path        string `mapstructure:"-"`
+	Context     string `mapstructure:"context"`
+	Model       string `mapstructure:"model"`
+	HaikuModel  string `mapstructure:"haiku_model"`
+	SonnetModel string `mapstructure:"sonnet_model"`
+	OpusModel   string `mapstructure:"opus_model"`
+}
+
+// FindProject searches dir and then each of its parents for a project file,
+// returning nil when none is found. Directories with a project file's name,
+// such as the default ~/.ccctx config directory, are skipped.
+func FindProject(dir string) (*Project, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		for _, name := range projectFiles {
+			path := filepath.Join(dir, name)
+			info, err := os.Stat(path)
+			if err != nil || !info.Mode().IsRegular() {
+				continue
+			}
+			return loadProject(path)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, nil
+		}
+		dir = parent
+	}
+}
+
+func loadProject(path string) (*Project, error) {
+	var project Project
+	if filepath.Ext(path) == ".toml" {
+		v := viper.New()
+		v.SetConfigFile(path)
+		v.SetConfigType("toml")
+		if err := v.ReadInConfig(); err != nil {
+			return nil, fmt.Errorf("failed to read project file '%s': %w", path, err)
+		}
+		if err := v.Unmarshal(&project); err != nil {
+			return nil, fmt.Errorf("failed to read project file '%s': %w", path, err)
+		}
+	} else {
+		name, err := readProjectName(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read project file '%s': %w", path, err)
+		}
+		project.Context = name
+	}
+
+	if project.Context == "" {
+		return nil, fmt.Errorf("project file '%s' does not name a context", path)
+	}
+	project.Path = path
+	return &project, nil
+}
+
+// readProjectName returns the first line of a plain project file that is
+// neither blank nor a # comment.
+func readProjectName(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			return line, nil
+		}
+	}
+	return "", scanner.Err()
+}
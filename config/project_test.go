@@ -0,0 +1,123 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindProject(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		dirs     []string
+		startDir string
+		want     *Project
+		wantPath string
+		wantErr  string
+	}{
+		{
+			name:     "no project file",
+			startDir: "repo/sub",
+		},
+		{
+			name: "toml file in start directory",
+			files: map[string]string{
+				"repo/.ccctx.toml": "context = \"work\"\nmodel = \"project-model\"\nopus_model = \"project-opus\"\n",
+			},
+			startDir: "repo",
+			want:     &Project{Context: "work", Model: "project-model", OpusModel: "project-opus"},
+			wantPath: "repo/.ccctx.toml",
+		},
+		{
+			name: "plain file found in a parent directory",
+			files: map[string]string{
+				"repo/.ccctx": "# gateway for this repo\n\nwork\n",
+			},
+			startDir: "repo/a/b",
+			want:     &Project{Context: "work"},
+			wantPath: "repo/.ccctx",
+		},
+		{
+			name: "nearest file wins",
+			files: map[string]string{
+				"repo/.ccctx.toml":     "context = \"outer\"\n",
+				"repo/sub/.ccctx.toml": "context = \"inner\"\n",
+			},
+			startDir: "repo/sub",
+			want:     &Project{Context: "inner"},
+			wantPath: "repo/sub/.ccctx.toml",
+		},
+		{
+			name: "toml file preferred over plain file in the same directory",
+			files: map[string]string{
+				"repo/.ccctx":      "plain\n",
+				"repo/.ccctx.toml": "context = \"toml\"\n",
+			},
+			startDir: "repo",
+			want:     &Project{Context: "toml"},
+			wantPath: "repo/.ccctx.toml",
+		},
+		{
+			name: "directory named .ccctx is skipped",
+			files: map[string]string{
+				".ccctx.toml": "context = \"root\"\n",
+			},
+			dirs:     []string{"repo/.ccctx"},
+			startDir: "repo",
+			want:     &Project{Context: "root"},
+			wantPath: ".ccctx.toml",
+		},
+		{
+			name: "toml file without context",
+			files: map[string]string{
+				"repo/.ccctx.toml": "model = \"project-model\"\n",
+			},
+			startDir: "repo",
+			wantErr:  "does not name a context",
+		},
+		{
+			name: "empty plain file",
+			files: map[string]string{
+				"repo/.ccctx": "# nothing here\n",
+			},
+			startDir: "repo",
+			wantErr:  "does not name a context",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			require.NoError(t, os.MkdirAll(filepath.Join(root, tt.startDir), 0755))
+			for _, dir := range tt.dirs {
+				require.NoError(t, os.MkdirAll(filepath.Join(root, dir), 0755))
+			}
+			for path, content := range tt.files {
+				require.NoError(t, os.WriteFile(filepath.Join(root, path), []byte(content), 0644))
+			}
+
+			got, err := FindProject(filepath.Join(root, tt.startDir))
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			if tt.want == nil {
+				// The temp dir's ancestors may hold a project file of their own
+				if got != nil {
+					assert.NotContains(t, got.Path, root)
+				}
+				return
+			}
+			require.NotNil(t, got)
+			assert.Equal(t, filepath.Join(root, tt.wantPath), got.Path)
+			got.Path = ""
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package config
+
+import "fmt"
+
+// Providers a context can reach Claude through.
+const (
+	ProviderAnthropic = "anthropic"
+	ProviderBedrock   = "bedrock"
+	ProviderVertex    = "vertex"
+)
+
+// Providers lists the values the provider field accepts.
+var Providers = []string{ProviderAnthropic, ProviderBedrock, ProviderVertex}
+
+// providerFields lists the fields each provider requires, in the order they
+// are checked.
+var providerFields = map[string][]string{
+	ProviderAnthropic: {"base_url", "auth_token"},
+	ProviderBedrock:   {"aws_region"},
+	ProviderVertex:    {"vertex_region", "vertex_project_id"},
+}
+
+// ProviderName returns the provider the context uses: its provider field, or
+// "anthropic" when that is unset.
+func (c *Context) ProviderName() string {
+	if c.Provider == "" {
+		return ProviderAnthropic
+	}
+	return c.Provider
+}
+
+// RequiredFields returns the keys of the fields the provider needs, or an
+// error for an unknown provider.
+func RequiredFields(provider string) ([]string, error) {
+	fields, ok := providerFields[provider]
+	if !ok {
+		return nil, fmt.Errorf("unknown provider '%s': use anthropic, bedrock or vertex", provider)
+	}
+	return fields, nil
+}
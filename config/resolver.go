@@ -0,0 +1,190 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Resolver returns the secret a "scheme:reference" value points to, given the
+// reference part. Errors must not include the secret itself.
+type Resolver func(ref string) (string, error)
+
+var (
+	resolversMu sync.RWMutex
+	resolvers   = map[string]Resolver{}
+)
+
+// cmdTimeout bounds how long a cmd: reference may run, leaving time for
+// password managers that prompt for a passphrase.
+var cmdTimeout = 30 * time.Second
+
+func init() {
+	RegisterResolver("env", lookupEnv)
+	RegisterResolver("file", readSecretFile)
+	RegisterResolver("cmd", runSecretCommand)
+	RegisterResolver("vault", readVaultSecret)
+}
+
+// RegisterResolver makes values of the form "scheme:reference" resolve through
+// fn wherever secret references are accepted. Registering a scheme again
+// replaces its resolver. Values whose prefix is not a registered scheme are
+// used literally.
+func RegisterResolver(scheme string, fn Resolver) {
+	if scheme == "" || strings.ContainsAny(scheme, ": ") {
+		panic(fmt.Sprintf("config: invalid resolver scheme %q", scheme))
+	}
+	if fn == nil {
+		panic("config: nil resolver for scheme " + scheme)
+	}
+	resolversMu.Lock()
+	defer resolversMu.Unlock()
+	resolvers[scheme] = fn
+}
+
+func lookupResolver(value string) (string, string, Resolver) {
+	scheme, ref, found := strings.Cut(value, ":")
+	if !found {
+		return "", "", nil
+	}
+	resolversMu.RLock()
+	defer resolversMu.RUnlock()
+	return scheme, ref, resolvers[scheme]
+}
+
+// ResolveSecret resolves value through the resolver registered for its scheme,
+// returning it unchanged when it carries no registered scheme.
+func ResolveSecret(value string) (string, error) {
+	_, ref, fn := lookupResolver(value)
+	if fn == nil {
+		return value, nil
+	}
+	return fn(ref)
+}
+
+// IsReference reports whether value is resolved through a registered scheme.
+func IsReference(value string) bool {
+	_, _, fn := lookupResolver(value)
+	return fn != nil
+}
+
+// ReferenceScheme returns the scheme value is resolved through, such as
+// "env", or "" when it is used literally.
+func ReferenceScheme(value string) string {
+	scheme, _, fn := lookupResolver(value)
+	if fn == nil {
+		return ""
+	}
+	return scheme
+}
+
+// ResolvesQuietly reports whether resolving value has no side effects: it is
+// literal or an env: or file: reference. Other references may run commands
+// or prompt for a passphrase.
+func ResolvesQuietly(value string) bool {
+	switch ReferenceScheme(value) {
+	case "", "env", "file":
+		return true
+	}
+	return false
+}
+
+// schemeOf names how value is resolved, for error messages.
+func schemeOf(value string) string {
+	scheme, _, fn := lookupResolver(value)
+	if fn == nil {
+		return "literal value"
+	}
+	return "scheme " + scheme
+}
+
+func lookupEnv(name string) (string, error) {
+	if name == "" {
+		return "", fmt.Errorf("environment variable name cannot be empty")
+	}
+
+	value := os.Getenv(name)
+	if value == "" {
+		return "", fmt.Errorf("environment variable '%s' is not set or empty", name)
+	}
+
+	return value, nil
+}
+
+func readSecretFile(path string) (string, error) {
+	if path == "" {
+		return "", fmt.Errorf("file path cannot be empty")
+	}
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		path = filepath.Join(home, rest)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	// Windows does not report meaningful permission bits
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0004 != 0 {
+		return "", fmt.Errorf("refusing to read world-readable file '%s' (run chmod o-r on it)", path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	secret := strings.TrimSpace(string(data))
+	if secret == "" {
+		return "", fmt.Errorf("file '%s' is empty", path)
+	}
+	return secret, nil
+}
+
+func runSecretCommand(command string) (string, error) {
+	if strings.TrimSpace(command) == "" {
+		return "", fmt.Errorf("command cannot be empty")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cmdTimeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	// Stdin and stderr stay attached so password managers can prompt
+	cmd.Stdin = os.Stdin
+	cmd.Stderr = os.Stderr
+	// Don't wait on grandchildren still holding stdout after a timeout kill
+	cmd.WaitDelay = time.Second
+
+	out, err := cmd.Output()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return "", fmt.Errorf("command timed out after %s", cmdTimeout)
+	}
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return "", fmt.Errorf("command exited with code %d", exitErr.ExitCode())
+		}
+		return "", fmt.Errorf("command failed to start: %w", err)
+	}
+
+	secret := strings.TrimSpace(string(out))
+	if secret == "" {
+		return "", fmt.Errorf("command produced no output")
+	}
+	return secret, nil
+}
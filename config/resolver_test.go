@@ -0,0 +1,205 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveSecret_File(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file permission checks are not enforced on windows")
+	}
+
+	tests := []struct {
+		name    string
+		content string
+		perm    os.FileMode
+		want    string
+		wantErr string
+	}{
+		{
+			name:    "token is read and trimmed",
+			content: "file-secret\n",
+			perm:    0600,
+			want:    "file-secret",
+		},
+		{
+			name:    "group-readable file is accepted",
+			content: "file-secret",
+			perm:    0640,
+			want:    "file-secret",
+		},
+		{
+			name:    "world-readable file is refused",
+			content: "file-secret",
+			perm:    0644,
+			wantErr: "refusing to read world-readable file",
+		},
+		{
+			name:    "empty file",
+			content: "  \n",
+			perm:    0600,
+			wantErr: "is empty",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "token")
+			require.NoError(t, os.WriteFile(path, []byte(tt.content), 0600))
+			require.NoError(t, os.Chmod(path, tt.perm))
+
+			got, err := ResolveSecret("file:" + path)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				assert.NotContains(t, err.Error(), "file-secret")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("missing file", func(t *testing.T) {
+		_, err := ResolveSecret("file:" + filepath.Join(t.TempDir(), "missing"))
+		require.Error(t, err)
+	})
+}
+
+func TestResolveSecret_Cmd(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("cmd tests use a POSIX shell")
+	}
+
+	tests := []struct {
+		name    string
+		command string
+		timeout time.Duration
+		want    string
+		wantErr string
+	}{
+		{
+			name:    "stdout is trimmed",
+			command: "printf 'cmd-secret\\n'",
+			want:    "cmd-secret",
+		},
+		{
+			name:    "shell syntax is supported",
+			command: "echo cmd-secret | tr a-z A-Z",
+			want:    "CMD-SECRET",
+		},
+		{
+			name:    "non-zero exit reports code without output",
+			command: "echo cmd-secret; exit 3",
+			wantErr: "command exited with code 3",
+		},
+		{
+			name:    "no output",
+			command: "true",
+			wantErr: "command produced no output",
+		},
+		{
+			name:    "timeout",
+			command: "exec sleep 5",
+			timeout: 100 * time.Millisecond,
+			wantErr: "command timed out after 100ms",
+		},
+		{
+			name:    "empty command",
+			command: "",
+			wantErr: "command cannot be empty",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.timeout != 0 {
+				original := cmdTimeout
+				cmdTimeout = tt.timeout
+				t.Cleanup(func() { cmdTimeout = original })
+			}
+
+			got, err := ResolveSecret("cmd:" + tt.command)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Equal(t, tt.wantErr, err.Error())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRegisterResolver(t *testing.T) {
+	RegisterResolver("test-vault", func(ref string) (string, error) {
+		if ref == "work" {
+			return "custom-secret", nil
+		}
+		return "", errors.New("no such entry")
+	})
+	t.Cleanup(func() {
+		resolversMu.Lock()
+		delete(resolvers, "test-vault")
+		resolversMu.Unlock()
+	})
+
+	got, err := ResolveSecret("test-vault:work")
+	require.NoError(t, err)
+	assert.Equal(t, "custom-secret", got)
+
+	_, err = ResolveSecret("test-vault:missing")
+	require.Error(t, err)
+	assert.Equal(t, "no such entry", err.Error())
+
+	got, err = ResolveSecret("unregistered:value")
+	require.NoError(t, err)
+	assert.Equal(t, "unregistered:value", got)
+
+	assert.Panics(t, func() { RegisterResolver("", func(string) (string, error) { return "", nil }) })
+	assert.Panics(t, func() { RegisterResolver("nil", nil) })
+}
+
+func TestGetContext_ResolverErrors(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("cmd tests use a POSIX shell")
+	}
+
+	tests := []struct {
+		name      string
+		authToken string
+		wantErr   string
+	}{
+		{
+			name:      "env scheme",
+			authToken: "env:CCCTX_TEST_UNSET_TOKEN",
+			wantErr:   "failed to resolve auth token for context 'work' (scheme env): environment variable 'CCCTX_TEST_UNSET_TOKEN' is not set or empty",
+		},
+		{
+			name:      "cmd scheme does not leak output",
+			authToken: "cmd:echo leaked-secret; exit 1",
+			wantErr:   "failed to resolve auth token for context 'work' (scheme cmd): command exited with code 1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "config.toml")
+			configTOML := "[context.work]\nbase_url = \"https://api.example.com\"\nauth_token = \"" + tt.authToken + "\"\n"
+			require.NoError(t, os.WriteFile(configPath, []byte(configTOML), 0600))
+			t.Setenv("CCCTX_CONFIG_PATH", configPath)
+
+			_, err := GetContext("work")
+			require.Error(t, err)
+			assert.Equal(t, tt.wantErr, err.Error())
+		})
+	}
+}
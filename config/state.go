@@ -0,0 +1,139 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/dsdashun/ccctx/internal/fsutil"
+)
+
+// State is the selection state persisted by 'ccctx use'.
+type State struct {
+	Current  string `json:"current,omitempty"`
+	Previous string `json:"previous,omitempty"`
+}
+
+// GetStatePath returns the path of the state file, which lives next to the
+// config file.
+func GetStatePath() (string, error) {
+	return pathInConfigDir("state.json")
+}
+
+// GetHistoryPath returns the path of the log of past runs, which lives next
+// to the config file.
+func GetHistoryPath() (string, error) {
+	return pathInConfigDir("history.jsonl")
+}
+
+// GetModelsCachePath returns the path of the cache of model lists fetched
+// by 'ccctx models', which lives next to the config file.
+func GetModelsCachePath() (string, error) {
+	return pathInConfigDir("models-cache.json")
+}
+
+// LoadState reads the state file. A missing file yields an empty state.
+func LoadState() (*State, error) {
+	path, err := GetStatePath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &State{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse state file: %w", err)
+	}
+	return &state, nil
+}
+
+func saveState(state *State) error {
+	path, err := GetStatePath()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return fsutil.WriteFileAtomic(path, append(data, '\n'), 0600)
+}
+
+// UseContext makes name the current context and remembers the one it
+// replaces. The name "-" switches back to the previous context.
+func UseContext(name string) (*State, error) {
+	state, err := LoadState()
+	if err != nil {
+		return nil, err
+	}
+	if name == "-" {
+		if state.Previous == "" {
+			return nil, fmt.Errorf("no previous context")
+		}
+		name = state.Previous
+	}
+
+	contexts, err := ListContexts()
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(contexts, name) {
+		return nil, fmt.Errorf("context '%s' not found", name)
+	}
+
+	if name != state.Current {
+		state.Previous, state.Current = state.Current, name
+		if err := saveState(state); err != nil {
+			return nil, err
+		}
+	}
+	return state, nil
+}
+
+// GetCurrentContext returns the context selected with 'ccctx use', or "" when
+// none is set. A current context that was since removed from the config is an
+// error rather than silently ignored.
+func GetCurrentContext() (string, error) {
+	state, err := LoadState()
+	if err != nil {
+		return "", err
+	}
+	if state.Current == "" {
+		return "", nil
+	}
+	contexts, err := ListContexts()
+	if err != nil {
+		return "", err
+	}
+	if !slices.Contains(contexts, state.Current) {
+		return "", fmt.Errorf("current context '%s' no longer exists; run 'ccctx use <context>'", state.Current)
+	}
+	return state.Current, nil
+}
+
+// renameInState points state entries naming oldName at newName, or clears
+// them when newName is empty.
+func renameInState(oldName, newName string) error {
+	state, err := LoadState()
+	if err != nil {
+		return err
+	}
+	changed := false
+	for _, field := range []*string{&state.Current, &state.Previous} {
+		if *field == oldName {
+			*field = newName
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return saveState(state)
+}
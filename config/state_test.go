@@ -0,0 +1,147 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUseContext(t *testing.T) {
+	tests := []struct {
+		name         string
+		steps        []string
+		wantCurrent  string
+		wantPrevious string
+		wantErr      string
+	}{
+		{
+			name:        "first use sets current",
+			steps:       []string{"work"},
+			wantCurrent: "work",
+		},
+		{
+			name:         "switching remembers previous",
+			steps:        []string{"work", "personal"},
+			wantCurrent:  "personal",
+			wantPrevious: "work",
+		},
+		{
+			name:         "dash flips back to previous",
+			steps:        []string{"work", "personal", "-"},
+			wantCurrent:  "work",
+			wantPrevious: "personal",
+		},
+		{
+			name:         "dash twice returns to where it started",
+			steps:        []string{"work", "personal", "-", "-"},
+			wantCurrent:  "personal",
+			wantPrevious: "work",
+		},
+		{
+			name:         "reusing the current context keeps previous",
+			steps:        []string{"work", "personal", "personal"},
+			wantCurrent:  "personal",
+			wantPrevious: "work",
+		},
+		{
+			name:    "dash without previous",
+			steps:   []string{"-"},
+			wantErr: "no previous context",
+		},
+		{
+			name:    "unknown context",
+			steps:   []string{"missing"},
+			wantErr: "context 'missing' not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "config.toml")
+			configTOML := "[context.work]\nbase_url = \"https://work.example.com\"\n\n[context.personal]\nbase_url = \"https://personal.example.com\"\n"
+			require.NoError(t, os.WriteFile(configPath, []byte(configTOML), 0600))
+			t.Setenv("CCCTX_CONFIG_PATH", configPath)
+
+			var err error
+			for _, step := range tt.steps {
+				if _, err = UseContext(step); err != nil {
+					break
+				}
+			}
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Equal(t, tt.wantErr, err.Error())
+				return
+			}
+			require.NoError(t, err)
+
+			state, err := LoadState()
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantCurrent, state.Current)
+			assert.Equal(t, tt.wantPrevious, state.Previous)
+
+			current, err := GetCurrentContext()
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantCurrent, current)
+
+			statePath, err := GetStatePath()
+			require.NoError(t, err)
+			info, err := os.Stat(statePath)
+			require.NoError(t, err)
+			assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+		})
+	}
+}
+
+func TestGetCurrentContext(t *testing.T) {
+	tests := []struct {
+		name      string
+		stateJSON string
+		want      string
+		wantErr   string
+	}{
+		{
+			name: "no state file",
+			want: "",
+		},
+		{
+			name:      "current context exists",
+			stateJSON: `{"current": "work"}`,
+			want:      "work",
+		},
+		{
+			name:      "current context was removed",
+			stateJSON: `{"current": "gone"}`,
+			wantErr:   "current context 'gone' no longer exists",
+		},
+		{
+			name:      "corrupt state file",
+			stateJSON: `{`,
+			wantErr:   "failed to parse state file",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			configPath := filepath.Join(tmpDir, "config.toml")
+			require.NoError(t, os.WriteFile(configPath, []byte("[context.work]\nbase_url = \"https://work.example.com\"\n"), 0600))
+			t.Setenv("CCCTX_CONFIG_PATH", configPath)
+			if tt.stateJSON != "" {
+				require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "state.json"), []byte(tt.stateJSON), 0600))
+			}
+
+			got, err := GetCurrentContext()
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
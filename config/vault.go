@@ -0,0 +1,65 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+
+	"github.com/dsdashun/ccctx/internal/vault"
+)
+
+var (
+	vaultsMu sync.Mutex
+	// vaults keeps unlocked vaults by path so several vault: references in
+	// one process prompt at most once.
+	vaults = map[string]*vault.Vault{}
+)
+
+// GetVaultPath returns the path of the encrypted token vault, which lives
+// next to the config file.
+func GetVaultPath() (string, error) {
+	return pathInConfigDir("vault.json")
+}
+
+func readVaultSecret(name string) (string, error) {
+	path, err := GetVaultPath()
+	if err != nil {
+		return "", err
+	}
+	return readVaultSecretAt(path, name)
+}
+
+func readVaultSecretAt(path, name string) (string, error) {
+	if name == "" {
+		return "", fmt.Errorf("vault secret name cannot be empty")
+	}
+
+	vaultsMu.Lock()
+	defer vaultsMu.Unlock()
+	v, cached := vaults[path]
+	if !cached {
+		var err error
+		v, err = vault.Open(path)
+		if err != nil {
+			return "", err
+		}
+		if !v.Exists() {
+			return "", fmt.Errorf("no vault at '%s'; add secrets with 'ccctx vault set'", path)
+		}
+		if err := v.Unlock(); err != nil {
+			return "", err
+		}
+		vaults[path] = v
+	}
+	return v.Get(name)
+}
+
+// ResolveSecret is the package-level ResolveSecret, except that vault:
+// references read the vault next to the file c was loaded from.
+func (c *Config) ResolveSecret(value string) (string, error) {
+	scheme, ref, fn := lookupResolver(value)
+	if scheme == "vault" && fn != nil && c.Path != "" {
+		return readVaultSecretAt(filepath.Join(filepath.Dir(c.Path), "vault.json"), ref)
+	}
+	return ResolveSecret(value)
+}
@@ -0,0 +1,52 @@
+package gateway
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/dsdashun/ccctx/internal/fsutil"
+)
+
+// cachedModels is a model list as fetched for one context.
+type cachedModels struct {
+	BaseURL string    `json:"base_url"`
+	Fetched time.Time `json:"fetched"`
+	Models  []Model   `json:"models"`
+}
+
+// CachedModels returns the models cached at path for the named context and
+// when they were fetched. It returns nil when none were cached, when they
+// came from a base URL other than baseURL, or when they are older than
+// maxAge. An unreadable cache counts as empty.
+func CachedModels(path, name, baseURL string, maxAge time.Duration) ([]Model, time.Time) {
+	cache := readCache(path)
+	entry, ok := cache[name]
+	if !ok || entry.BaseURL != baseURL || time.Since(entry.Fetched) > maxAge {
+		return nil, time.Time{}
+	}
+	return entry.Models, entry.Fetched
+}
+
+// CacheModels stores the models fetched for the named context from baseURL.
+func CacheModels(path, name, baseURL string, models []Model) error {
+	cache := readCache(path)
+	cache[name] = cachedModels{BaseURL: baseURL, Fetched: time.Now().UTC(), Models: models}
+	data, err := json.MarshalIndent(cache, "", "  ")
+	if err != nil {
+		return err
+	}
+	return fsutil.WriteFileAtomic(path, append(data, '\n'), 0600)
+}
+
+func readCache(path string) map[string]cachedModels {
+	cache := map[string]cachedModels{}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cache
+	}
+	if json.Unmarshal(data, &cache) != nil {
+		return map[string]cachedModels{}
+	}
+	return cache
+}
@@ -0,0 +1,42 @@
+package gateway
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestModelCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "models-cache.json")
+	work := []Model{{ID: "work-model"}}
+
+	models, _ := CachedModels(path, "work", "https://work.example.com", time.Hour)
+	assert.Nil(t, models, "no cache yet")
+
+	require.NoError(t, CacheModels(path, "work", "https://work.example.com", work))
+	require.NoError(t, CacheModels(path, "personal", "https://personal.example.com", []Model{{ID: "personal-model"}}))
+
+	models, fetched := CachedModels(path, "work", "https://work.example.com", time.Hour)
+	assert.Equal(t, work, models)
+	assert.WithinDuration(t, time.Now(), fetched, time.Minute)
+
+	models, _ = CachedModels(path, "work", "https://moved.example.com", time.Hour)
+	assert.Nil(t, models, "a changed base URL invalidates the cache")
+	models, _ = CachedModels(path, "work", "https://work.example.com", 0)
+	assert.Nil(t, models, "stale entries are ignored")
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	require.NoError(t, os.WriteFile(path, []byte("{not json"), 0600))
+	models, _ = CachedModels(path, "work", "https://work.example.com", time.Hour)
+	assert.Nil(t, models, "a damaged cache counts as empty")
+	require.NoError(t, CacheModels(path, "work", "https://work.example.com", work))
+	models, _ = CachedModels(path, "work", "https://work.example.com", time.Hour)
+	assert.Equal(t, work, models)
+}
@@ -0,0 +1,154 @@
+package gateway
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// NewClient returns a client that reaches the gateway the way claude would
+// when started with environ, in os.Environ form: through the proxy named by
+// HTTPS_PROXY or HTTP_PROXY unless NO_PROXY exempts the host, trusting the
+// certificates in NODE_EXTRA_CA_CERTS besides the system ones, and sending
+// the headers listed in ANTHROPIC_CUSTOM_HEADERS.
+func NewClient(environ []string) (*http.Client, error) {
+	env := make(map[string]string, len(environ))
+	for _, e := range environ {
+		name, value, _ := strings.Cut(e, "=")
+		env[name] = value
+	}
+	getenv := func(name string) string {
+		if value := env[name]; value != "" {
+			return value
+		}
+		return env[strings.ToLower(name)]
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = proxyFunc(getenv("HTTPS_PROXY"), getenv("HTTP_PROXY"), getenv("NO_PROXY"))
+	if path := env["NODE_EXTRA_CA_CERTS"]; path != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		pem, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read NODE_EXTRA_CA_CERTS: %w", err)
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in NODE_EXTRA_CA_CERTS file %s", path)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+
+	headers := parseHeaders(env["ANTHROPIC_CUSTOM_HEADERS"])
+	if len(headers) == 0 {
+		return &http.Client{Transport: transport}, nil
+	}
+	return &http.Client{Transport: headerTransport{base: transport, headers: headers}}, nil
+}
+
+// parseHeaders reads ANTHROPIC_CUSTOM_HEADERS: one "Name: Value" per line.
+func parseHeaders(value string) http.Header {
+	headers := http.Header{}
+	for _, line := range strings.Split(value, "\n") {
+		name, value, ok := strings.Cut(line, ":")
+		if name = strings.TrimSpace(name); ok && name != "" {
+			headers.Add(name, strings.TrimSpace(value))
+		}
+	}
+	return headers
+}
+
+// headerTransport adds custom headers to every request.
+type headerTransport struct {
+	base    http.RoundTripper
+	headers http.Header
+}
+
+func (t headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for name, values := range t.headers {
+		req.Header[name] = append(req.Header[name], values...)
+	}
+	return t.base.RoundTrip(req)
+}
+
+// proxyFunc picks a proxy the way http.ProxyFromEnvironment does, but from
+// the given values instead of the process environment, which it caches.
+// Loopback hosts are never proxied.
+func proxyFunc(httpsProxy, httpProxy, noProxy string) func(*http.Request) (*url.URL, error) {
+	return func(req *http.Request) (*url.URL, error) {
+		proxy := httpProxy
+		if req.URL.Scheme == "https" {
+			proxy = httpsProxy
+		}
+		if proxy == "" || !useProxy(req.URL, noProxy) {
+			return nil, nil
+		}
+		u, err := url.Parse(proxy)
+		if err != nil || u.Host == "" {
+			// Like curl, take "proxy:3128" to mean http://proxy:3128
+			if u, err = url.Parse("http://" + proxy); err != nil {
+				return nil, fmt.Errorf("invalid proxy address %q: %w", proxy, err)
+			}
+		}
+		return u, nil
+	}
+}
+
+// useProxy reports whether requests to u go through the proxy given the
+// NO_PROXY list: "*", or comma-separated host names, domains (matching their
+// subdomains, with or without a leading dot), IP addresses and CIDR ranges,
+// each optionally with a port.
+func useProxy(u *url.URL, noProxy string) bool {
+	host := strings.ToLower(u.Hostname())
+	if host == "localhost" {
+		return false
+	}
+	ip := net.ParseIP(host)
+	if ip != nil && ip.IsLoopback() {
+		return false
+	}
+	port := u.Port()
+	if port == "" {
+		port = map[string]string{"http": "80", "https": "443"}[u.Scheme]
+	}
+	for _, entry := range strings.Split(noProxy, ",") {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		switch {
+		case entry == "":
+			continue
+		case entry == "*":
+			return false
+		}
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			if ip != nil && network.Contains(ip) {
+				return false
+			}
+			continue
+		}
+		if h, p, err := net.SplitHostPort(entry); err == nil {
+			if p != port {
+				continue
+			}
+			entry = h
+		}
+		if entryIP := net.ParseIP(entry); entryIP != nil {
+			if ip != nil && entryIP.Equal(ip) {
+				return false
+			}
+			continue
+		}
+		domain := strings.TrimPrefix(entry, ".")
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,84 @@
+package gateway
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewClient(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Team") != "core" || r.Header.Get("X-Route") != "eu" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Write([]byte(`{"data":[]}`))
+	}))
+	defer server.Close()
+
+	caPath := filepath.Join(t.TempDir(), "ca.pem")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	require.NoError(t, os.WriteFile(caPath, caPEM, 0600))
+
+	client, err := NewClient([]string{
+		"NODE_EXTRA_CA_CERTS=" + caPath,
+		"ANTHROPIC_CUSTOM_HEADERS=X-Team: core\nX-Route: eu",
+	})
+	require.NoError(t, err)
+	result := Ping(context.Background(), client, server.URL, "good-token")
+	require.NoError(t, result.Err)
+	assert.True(t, result.OK(), result.StatusCode)
+
+	// Without the CA the server's certificate is not trusted
+	client, err = NewClient(nil)
+	require.NoError(t, err)
+	assert.Error(t, Ping(context.Background(), client, server.URL, "good-token").Err)
+
+	_, err = NewClient([]string{"NODE_EXTRA_CA_CERTS=" + filepath.Join(t.TempDir(), "missing.pem")})
+	assert.ErrorContains(t, err, "failed to read NODE_EXTRA_CA_CERTS")
+}
+
+func TestProxyFunc(t *testing.T) {
+	tests := []struct {
+		name    string
+		target  string
+		noProxy string
+		want    string
+	}{
+		{name: "https uses HTTPS_PROXY", target: "https://gateway.example.com", want: "http://secure-proxy:3128"},
+		{name: "http uses HTTP_PROXY", target: "http://gateway.example.com", want: "http://plain-proxy:8080"},
+		{name: "loopback is never proxied", target: "https://127.0.0.1:8443"},
+		{name: "localhost is never proxied", target: "https://localhost"},
+		{name: "star", target: "https://gateway.example.com", noProxy: "*"},
+		{name: "domain matches subdomains", target: "https://gateway.example.com", noProxy: "other.org, example.com"},
+		{name: "leading dot", target: "https://gateway.example.com", noProxy: ".example.com"},
+		{name: "similar suffix is not a subdomain", target: "https://gateway.badexample.com", noProxy: "example.com", want: "http://secure-proxy:3128"},
+		{name: "port must match", target: "https://gateway.example.com", noProxy: "gateway.example.com:8443", want: "http://secure-proxy:3128"},
+		{name: "default port", target: "https://gateway.example.com", noProxy: "gateway.example.com:443"},
+		{name: "cidr", target: "https://10.1.2.3", noProxy: "10.0.0.0/8"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proxy := proxyFunc("secure-proxy:3128", "http://plain-proxy:8080", tt.noProxy)
+			target, err := url.Parse(tt.target)
+			require.NoError(t, err)
+			got, err := proxy(&http.Request{URL: target})
+			require.NoError(t, err)
+			if tt.want == "" {
+				assert.Nil(t, got)
+			} else {
+				require.NotNil(t, got)
+				assert.Equal(t, tt.want, got.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,164 @@
+// Package gateway talks to the Anthropic-compatible API behind a context's
+// base_url.
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// APIVersion is the anthropic-version header sent with requests.
+const APIVersion = "2023-06-01"
+
+// maxErrorBody bounds how much of an error response is read for its message.
+const maxErrorBody = 64 << 10
+
+// Result is the outcome of a Ping.
+type Result struct {
+	// StatusCode is the HTTP status of the response, or 0 when none arrived.
+	StatusCode int
+	// Latency is the time until the response headers arrived.
+	Latency time.Duration
+	// Message is the error message from a non-2xx response body, if any.
+	Message string
+	// Err is set when the request could not be made or got no response.
+	Err error
+}
+
+// OK reports whether the gateway accepted the request.
+func (r Result) OK() bool {
+	return r.Err == nil && r.StatusCode >= 200 && r.StatusCode < 300
+}
+
+// AuthFailed reports whether the gateway rejected the token.
+func (r Result) AuthFailed() bool {
+	return r.StatusCode == http.StatusUnauthorized || r.StatusCode == http.StatusForbidden
+}
+
+// newRequest builds a GET request for the API path below baseURL, with token
+// sent the way claude sends ANTHROPIC_AUTH_TOKEN.
+func newRequest(ctx context.Context, baseURL, token string, query url.Values, path ...string) (*http.Request, error) {
+	endpoint, err := url.JoinPath(baseURL, path...)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base_url: %w", err)
+	}
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("anthropic-version", APIVersion)
+	return req, nil
+}
+
+// Ping sends a minimal authenticated request, GET /v1/models, to the gateway
+// at baseURL.
+func Ping(ctx context.Context, client *http.Client, baseURL, token string) Result {
+	req, err := newRequest(ctx, baseURL, token, nil, "v1", "models")
+	if err != nil {
+		return Result{Err: err}
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	latency := time.Since(start)
+	if err != nil {
+		return Result{Latency: latency, Err: err}
+	}
+	defer resp.Body.Close()
+
+	result := Result{StatusCode: resp.StatusCode, Latency: latency}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		result.Message = errorMessage(body)
+	}
+	return result
+}
+
+// errorMessage extracts the message from an API error body such as
+// {"type":"error","error":{"type":"authentication_error","message":"..."}},
+// falling back to the body's first line for gateways that answer otherwise.
+func errorMessage(body []byte) string {
+	var apiErr struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(body, &apiErr) == nil && apiErr.Error.Message != "" {
+		return apiErr.Error.Message
+	}
+	line, _, _ := strings.Cut(strings.TrimSpace(string(body)), "\n")
+	if len(line) > 200 {
+		line = line[:200] + "..."
+	}
+	return line
+}
+
+// Model is a model the gateway offers.
+type Model struct {
+	ID          string    `json:"id"`
+	DisplayName string    `json:"display_name,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// maxModelPages bounds how many pages ListModels follows, in case a gateway
+// keeps reporting more.
+const maxModelPages = 20
+
+// ListModels returns the models listed by GET /v1/models, following its
+// pagination, in the order the gateway lists them.
+func ListModels(ctx context.Context, client *http.Client, baseURL, token string) ([]Model, error) {
+	var models []Model
+	query := url.Values{"limit": {"1000"}}
+	for range maxModelPages {
+		req, err := newRequest(ctx, baseURL, token, query, "v1", "models")
+		if err != nil {
+			return nil, err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		var page struct {
+			Data    []Model `json:"data"`
+			HasMore bool    `json:"has_more"`
+			LastID  string  `json:"last_id"`
+		}
+		if err := decodeResponse(resp, &page); err != nil {
+			return nil, err
+		}
+		models = append(models, page.Data...)
+		if !page.HasMore || page.LastID == "" {
+			break
+		}
+		query.Set("after_id", page.LastID)
+	}
+	return models, nil
+}
+
+// decodeResponse decodes a successful JSON response into v, turning any
+// other status into an error carrying the gateway's message.
+func decodeResponse(resp *http.Response, v any) error {
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		message := errorMessage(body)
+		if message == "" {
+			message = http.StatusText(resp.StatusCode)
+		}
+		return fmt.Errorf("gateway returned %d: %s", resp.StatusCode, message)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("invalid response from gateway: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPing(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Header.Get("anthropic-version") != APIVersion:
+			w.WriteHeader(http.StatusBadRequest)
+		case r.URL.Path == "/broken/v1/models":
+			w.WriteHeader(http.StatusBadGateway)
+			w.Write([]byte("upstream unavailable\nmore detail"))
+		case r.URL.Path != "/v1/models" && r.URL.Path != "/proxy/v1/models":
+			w.WriteHeader(http.StatusNotFound)
+		case r.Header.Get("Authorization") != "Bearer good-token":
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"type":"error","error":{"type":"authentication_error","message":"invalid x-api-key"}}`))
+		default:
+			w.Write([]byte(`{"data":[]}`))
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name        string
+		baseURL     string
+		token       string
+		wantStatus  int
+		wantOK      bool
+		wantAuth    bool
+		wantMessage string
+	}{
+		{
+			name:       "accepted",
+			baseURL:    server.URL,
+			token:      "good-token",
+			wantStatus: http.StatusOK,
+			wantOK:     true,
+		},
+		{
+			name:       "base url with a path and trailing slash",
+			baseURL:    server.URL + "/proxy/",
+			token:      "good-token",
+			wantStatus: http.StatusOK,
+			wantOK:     true,
+		},
+		{
+			name:        "rejected token",
+			baseURL:     server.URL,
+			token:       "revoked-token",
+			wantStatus:  http.StatusUnauthorized,
+			wantAuth:    true,
+			wantMessage: "invalid x-api-key",
+		},
+		{
+			name:        "non-JSON error body",
+			baseURL:     server.URL + "/broken",
+			token:       "good-token",
+			wantStatus:  http.StatusBadGateway,
+			wantMessage: "upstream unavailable",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Ping(context.Background(), server.Client(), tt.baseURL, tt.token)
+			require.NoError(t, result.Err)
+			assert.Equal(t, tt.wantStatus, result.StatusCode)
+			assert.Equal(t, tt.wantOK, result.OK())
+			assert.Equal(t, tt.wantAuth, result.AuthFailed())
+			assert.Equal(t, tt.wantMessage, result.Message)
+			assert.Positive(t, result.Latency)
+		})
+	}
+}
+
+func TestPing_Timeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	result := Ping(ctx, server.Client(), server.URL, "good-token")
+	assert.True(t, errors.Is(result.Err, context.DeadlineExceeded), result.Err)
+	assert.False(t, result.OK())
+	assert.Zero(t, result.StatusCode)
+}
+
+func TestListModels(t *testing.T) {
+	pages := map[string]string{
+		"":                `{"data":[{"id":"claude-opus-4-7","display_name":"Claude Opus 4.7","created_at":"2026-09-01T00:00:00Z"}],"has_more":true,"last_id":"claude-opus-4-7"}`,
+		"claude-opus-4-7": `{"data":[{"id":"claude-haiku-4-5","display_name":"Claude Haiku 4.5"}],"has_more":false,"last_id":"claude-haiku-4-5"}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer good-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"type":"error","error":{"type":"authentication_error","message":"invalid x-api-key"}}`))
+			return
+		}
+		page, ok := pages[r.URL.Query().Get("after_id")]
+		if !ok || r.URL.Query().Get("limit") != "1000" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(page))
+	}))
+	defer server.Close()
+
+	models, err := ListModels(context.Background(), server.Client(), server.URL, "good-token")
+	require.NoError(t, err)
+	assert.Equal(t, []Model{
+		{ID: "claude-opus-4-7", DisplayName: "Claude Opus 4.7", CreatedAt: time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)},
+		{ID: "claude-haiku-4-5", DisplayName: "Claude Haiku 4.5"},
+	}, models)
+
+	_, err = ListModels(context.Background(), server.Client(), server.URL, "revoked-token")
+	assert.EqualError(t, err, "gateway returned 401: invalid x-api-key")
+}
@@ -0,0 +1,127 @@
+// Package history keeps a log of the commands run and exec launched, one JSON
+// object per line. Writers take an exclusive lock on the file and readers a
+// shared one, so concurrent sessions never interleave or lose entries.
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+	"time"
+)
+
+// DefaultLimit is how many entries are kept when $CCCTX_HISTORY_LIMIT is unset.
+const DefaultLimit = 1000
+
+// Entry records one launch. Command holds the program alone: arguments are
+// not recorded, since they may hold prompts or secrets.
+type Entry struct {
+	Context  string        `json:"context"`
+	Start    time.Time     `json:"start"`
+	Command  []string      `json:"command"`
+	ExitCode int           `json:"exit_code"`
+	Duration time.Duration `json:"duration"`
+	// Replaced marks launches where ccctx replaced itself with the command,
+	// so the exit code and duration are unknown.
+	Replaced bool `json:"replaced,omitempty"`
+}
+
+// Limit reads $CCCTX_HISTORY_LIMIT, the number of entries to keep; "0"
+// disables recording.
+func Limit() int {
+	if v := os.Getenv("CCCTX_HISTORY_LIMIT"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			return n
+		}
+	}
+	return DefaultLimit
+}
+
+// Append adds e to the history file at path, then drops the oldest entries
+// beyond limit. A limit of 0 records nothing.
+func Append(path string, e Entry, limit int) error {
+	if limit <= 0 {
+		return nil
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := lock(f, true); err != nil {
+		return err
+	}
+	defer unlock(f)
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return err
+	}
+	// A writer that died mid-line leaves a fragment; start on a fresh line
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		data = append(data, '\n')
+	}
+	data = append(data, line...)
+
+	if n := bytes.Count(data, []byte("\n")); n > limit {
+		// Rewrite in place rather than renaming a new file over it, so the
+		// lock other processes wait on stays on the same file
+		for ; n > limit; n-- {
+			data = data[bytes.IndexByte(data, '\n')+1:]
+		}
+		if err := f.Truncate(0); err != nil {
+			return err
+		}
+	}
+	_, err = f.WriteAt(data, 0)
+	return err
+}
+
+// Load returns the entries of the history file at path, oldest first. A
+// missing file yields no entries, and lines that do not parse are skipped.
+func Load(path string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if err := lock(f, false); err != nil {
+		return nil, err
+	}
+	defer unlock(f)
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err == nil {
+			entries = append(entries, e)
+		}
+	}
+	return entries, scanner.Err()
+}
+
+// LastUsed returns when each context was last launched.
+func LastUsed(entries []Entry) map[string]time.Time {
+	last := map[string]time.Time{}
+	for _, e := range entries {
+		if e.Start.After(last[e.Context]) {
+			last[e.Context] = e.Start
+		}
+	}
+	return last
+}
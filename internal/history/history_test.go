@@ -0,0 +1,119 @@
+package history
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func entry(context string, minute int) Entry {
+	return Entry{
+		Context:  context,
+		Start:    time.Date(2026, 10, 18, 12, minute, 0, 0, time.UTC),
+		Command:  []string{"claude", "--version"},
+		ExitCode: minute % 2,
+		Duration: time.Duration(minute) * time.Second,
+	}
+}
+
+func TestAppendAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+
+	entries, err := Load(path)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	want := []Entry{entry("work", 1), entry("personal", 2), entry("work", 3)}
+	for _, e := range want {
+		require.NoError(t, Append(path, e, 10))
+	}
+	entries, err = Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, want, entries)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
+
+func TestAppend_Limit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	for i := range 5 {
+		require.NoError(t, Append(path, entry("work", i), 3))
+	}
+	entries, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, []Entry{entry("work", 2), entry("work", 3), entry("work", 4)}, entries)
+
+	require.NoError(t, Append(path, entry("work", 5), 0))
+	entries, err = Load(path)
+	require.NoError(t, err)
+	assert.Len(t, entries, 3, "a limit of 0 records nothing")
+}
+
+func TestAppend_SkipsDamagedLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	require.NoError(t, Append(path, entry("work", 1), 10))
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"context":"trunc`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	require.NoError(t, Append(path, entry("work", 2), 10))
+	entries, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, []Entry{entry("work", 1), entry("work", 2)}, entries)
+}
+
+func TestAppend_Concurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	const writers, each = 8, 20
+
+	var wg sync.WaitGroup
+	for w := range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range each {
+				assert.NoError(t, Append(path, entry(fmt.Sprintf("ctx-%d", w), i), 1000))
+			}
+		}()
+	}
+	wg.Wait()
+
+	entries, err := Load(path)
+	require.NoError(t, err)
+	assert.Len(t, entries, writers*each)
+}
+
+func TestLimit(t *testing.T) {
+	tests := []struct {
+		value string
+		want  int
+	}{
+		{"", DefaultLimit},
+		{"50", 50},
+		{"0", 0},
+		{"-1", DefaultLimit},
+		{"lots", DefaultLimit},
+	}
+	for _, tt := range tests {
+		t.Setenv("CCCTX_HISTORY_LIMIT", tt.value)
+		assert.Equal(t, tt.want, Limit(), tt.value)
+	}
+}
+
+func TestLastUsed(t *testing.T) {
+	last := LastUsed([]Entry{entry("work", 3), entry("personal", 2), entry("work", 1)})
+	assert.Equal(t, map[string]time.Time{
+		"work":     entry("work", 3).Start,
+		"personal": entry("personal", 2).Start,
+	}, last)
+}
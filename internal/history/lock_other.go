@@ -0,0 +1,11 @@
+//go:build !unix && !windows
+
+package history
+
+import "os"
+
+// Platforms without file locking get none; concurrent writers may then lose
+// entries but never break the process.
+func lock(f *os.File, exclusive bool) error { return nil }
+
+func unlock(f *os.File) error { return nil }
@@ -0,0 +1,45 @@
+// Package mask hides secrets in output meant for humans and scripts.
+package mask
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// minRevealLen is the shortest secret whose first and last revealLen
+// characters are shown; anything shorter is hidden entirely.
+const minRevealLen = 16
+
+// revealLen is how many leading and trailing characters of a long secret are
+// shown.
+const revealLen = 4
+
+// Secret masks the middle of value, keeping a short prefix, which tells an
+// "sk-ant-" key from a gateway token, and a short suffix, which tells tokens
+// of the same kind apart. Values too short to spare those are hidden
+// entirely. Empty values stay empty.
+func Secret(value string) string {
+	if value == "" {
+		return ""
+	}
+	if utf8.RuneCountInString(value) < minRevealLen {
+		return "****"
+	}
+	runes := []rune(value)
+	return string(runes[:revealLen]) + "****" + string(runes[len(runes)-revealLen:])
+}
+
+// secretWords mark variable names whose values are treated as secrets.
+var secretWords = []string{"TOKEN", "KEY", "SECRET", "PASSWORD", "CREDENTIAL", "HEADERS"}
+
+// IsSecretName reports whether an environment variable name suggests its
+// value is a secret, such as ANTHROPIC_API_KEY or ANTHROPIC_CUSTOM_HEADERS.
+func IsSecretName(name string) bool {
+	name = strings.ToUpper(name)
+	for _, word := range secretWords {
+		if strings.Contains(name, word) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,37 @@
+package mask
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecret(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{"empty", "", ""},
+		{"short", "abc", "****"},
+		{"just below the reveal length", "0123456789abcde", "****"},
+		{"sixteen characters", "0123456789abcdef", "0123****cdef"},
+		{"token", "sk-ant-REDACTED", "sk-a****wxyz"},
+		{"multibyte", "ééééééééééééééüü", "éééé****ééüü"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Secret(tt.value))
+		})
+	}
+}
+
+func TestIsSecretName(t *testing.T) {
+	for _, name := range []string{"ANTHROPIC_AUTH_TOKEN", "ANTHROPIC_API_KEY", "aws_secret_access_key", "ANTHROPIC_CUSTOM_HEADERS", "DB_PASSWORD"} {
+		assert.True(t, IsSecretName(name), name)
+	}
+	for _, name := range []string{"ANTHROPIC_BASE_URL", "HTTPS_PROXY", "DISABLE_TELEMETRY"} {
+		assert.False(t, IsSecretName(name), name)
+	}
+}
@@ -12,10 +12,25 @@ func validateFlagValue(name, value string) error {
 	return nil
 }
 
-// ExtractFlags extracts --model, --haiku-model, --sonnet-model, --opus-model, and --small-fast-model from args before the -- separator.
+// Flags holds the ccctx options recognized before the -- separator.
+type Flags struct {
+	Model       string
+	HaikuModel  string
+	SonnetModel string
+	OpusModel   string
+	// Select opens the interactive selector even when a current context is set.
+	Select bool
+	// DryRun prints what would be executed instead of executing it.
+	DryRun bool
+	// Replace execs the target in place of ccctx instead of running it as a
+	// child.
+	Replace bool
+}
+
+// ExtractFlags extracts --model, --haiku-model, --sonnet-model, --opus-model, --small-fast-model, --select, --dry-run and --replace from args before the -- separator.
 // --small-fast-model is an alias for --haiku-model (--haiku-model wins when both specified).
 // Extracted flags are removed from the returned remaining args.
-func ExtractFlags(args []string) (model, haikuModel, sonnetModel, opusModel string, remaining []string, err error) {
+func ExtractFlags(args []string) (flags Flags, remaining []string, err error) {
 	sepIdx := len(args)
 	for i, a := range args {
 		if a == "--" {
@@ -28,73 +43,56 @@ func ExtractFlags(args []string) (model, haikuModel, sonnetModel, opusModel stri
 	preSep := args[:sepIdx]
 	var sfmAlias string
 	var haikuModelSet bool
+	valueFlags := map[string]*string{
+		"--model":            &flags.Model,
+		"--haiku-model":      &flags.HaikuModel,
+		"--sonnet-model":     &flags.SonnetModel,
+		"--opus-model":       &flags.OpusModel,
+		"--small-fast-model": &sfmAlias,
+	}
 	i := 0
 	for i < len(preSep) {
-		switch preSep[i] {
-		case "--model":
-			if i+1 >= len(preSep) {
-				return "", "", "", "", []string{}, fmt.Errorf("--model requires a value")
-			}
-			if err := validateFlagValue("--model", preSep[i+1]); err != nil {
-				return "", "", "", "", []string{}, err
-			}
-			model = preSep[i+1]
-			i += 2
-		case "--haiku-model":
-			if i+1 >= len(preSep) {
-				return "", "", "", "", []string{}, fmt.Errorf("--haiku-model requires a value")
-			}
-			if err := validateFlagValue("--haiku-model", preSep[i+1]); err != nil {
-				return "", "", "", "", []string{}, err
-			}
-			haikuModel = preSep[i+1]
-			haikuModelSet = true
-			i += 2
-		case "--sonnet-model":
-			if i+1 >= len(preSep) {
-				return "", "", "", "", []string{}, fmt.Errorf("--sonnet-model requires a value")
-			}
-			if err := validateFlagValue("--sonnet-model", preSep[i+1]); err != nil {
-				return "", "", "", "", []string{}, err
-			}
-			sonnetModel = preSep[i+1]
-			i += 2
-		case "--opus-model":
+		arg := preSep[i]
+		if target, ok := valueFlags[arg]; ok {
 			if i+1 >= len(preSep) {
-				return "", "", "", "", []string{}, fmt.Errorf("--opus-model requires a value")
+				return Flags{}, []string{}, fmt.Errorf("%s requires a value", arg)
 			}
-			if err := validateFlagValue("--opus-model", preSep[i+1]); err != nil {
-				return "", "", "", "", []string{}, err
+			if err := validateFlagValue(arg, preSep[i+1]); err != nil {
+				return Flags{}, []string{}, err
 			}
-			opusModel = preSep[i+1]
-			i += 2
-		case "--small-fast-model":
-			if i+1 >= len(preSep) {
-				return "", "", "", "", []string{}, fmt.Errorf("--small-fast-model requires a value")
-			}
-			if err := validateFlagValue("--small-fast-model", preSep[i+1]); err != nil {
-				return "", "", "", "", []string{}, err
+			*target = preSep[i+1]
+			if arg == "--haiku-model" {
+				haikuModelSet = true
 			}
-			sfmAlias = preSep[i+1]
 			i += 2
+			continue
+		}
+
+		switch arg {
+		case "--select":
+			flags.Select = true
+		case "--dry-run":
+			flags.DryRun = true
+		case "--replace":
+			flags.Replace = true
 		default:
-			remaining = append(remaining, preSep[i])
-			i++
+			remaining = append(remaining, arg)
 		}
+		i++
 	}
 
 	// Resolve alias: --haiku-model wins over --small-fast-model.
 	// Only apply --small-fast-model if --haiku-model was never explicitly set,
 	// so that `--haiku-model ""` (explicit empty) is not silently overridden.
 	if !haikuModelSet && sfmAlias != "" {
-		haikuModel = sfmAlias
+		flags.HaikuModel = sfmAlias
 	}
 
 	if sepIdx < len(args) {
 		remaining = append(remaining, args[sepIdx:]...)
 	}
 
-	return model, haikuModel, sonnetModel, opusModel, remaining, nil
+	return flags, remaining, nil
 }
 
 // WantsHelp checks if --help or -h appears before -- in args.
@@ -470,7 +470,7 @@ func TestExtractFlags(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			model, haiku, sonnet, opus, remaining, err := ExtractFlags(tt.args)
+			flags, remaining, err := ExtractFlags(tt.args)
 
 			if tt.wantErr != "" {
 				require.Error(t, err)
@@ -480,10 +480,127 @@ func TestExtractFlags(t *testing.T) {
 			}
 
 			require.NoError(t, err)
-			assert.Equal(t, tt.wantModel, model)
-			assert.Equal(t, tt.wantHaikuModel, haiku)
-			assert.Equal(t, tt.wantSonnetModel, sonnet)
-			assert.Equal(t, tt.wantOpusModel, opus)
+			assert.Equal(t, tt.wantModel, flags.Model)
+			assert.Equal(t, tt.wantHaikuModel, flags.HaikuModel)
+			assert.Equal(t, tt.wantSonnetModel, flags.SonnetModel)
+			assert.Equal(t, tt.wantOpusModel, flags.OpusModel)
+			assert.Equal(t, tt.wantRemaining, remaining)
+		})
+	}
+}
+
+func TestExtractFlags_Select(t *testing.T) {
+	tests := []struct {
+		name          string
+		args          []string
+		wantSelect    bool
+		wantRemaining []string
+	}{
+		{
+			name:          "no --select",
+			args:          []string{"provider-A"},
+			wantSelect:    false,
+			wantRemaining: []string{"provider-A"},
+		},
+		{
+			name:          "--select alone",
+			args:          []string{"--select"},
+			wantSelect:    true,
+			wantRemaining: []string{},
+		},
+		{
+			name:          "--select with model flag and forwarded args",
+			args:          []string{"--select", "--model", "foo", "--", "--select"},
+			wantSelect:    true,
+			wantRemaining: []string{"--", "--select"},
+		},
+		{
+			name:          "--select after separator is forwarded",
+			args:          []string{"--", "--select"},
+			wantSelect:    false,
+			wantRemaining: []string{"--", "--select"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flags, remaining, err := ExtractFlags(tt.args)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantSelect, flags.Select)
+			assert.Equal(t, tt.wantRemaining, remaining)
+		})
+	}
+}
+
+func TestExtractFlags_DryRun(t *testing.T) {
+	tests := []struct {
+		name          string
+		args          []string
+		wantDryRun    bool
+		wantRemaining []string
+	}{
+		{
+			name:          "no --dry-run",
+			args:          []string{"provider-A"},
+			wantDryRun:    false,
+			wantRemaining: []string{"provider-A"},
+		},
+		{
+			name:          "--dry-run with context and model flag",
+			args:          []string{"provider-A", "--dry-run", "--model", "foo"},
+			wantDryRun:    true,
+			wantRemaining: []string{"provider-A"},
+		},
+		{
+			name:          "--dry-run after separator is forwarded",
+			args:          []string{"--", "--dry-run"},
+			wantDryRun:    false,
+			wantRemaining: []string{"--", "--dry-run"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flags, remaining, err := ExtractFlags(tt.args)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantDryRun, flags.DryRun)
+			assert.Equal(t, tt.wantRemaining, remaining)
+		})
+	}
+}
+
+func TestExtractFlags_Replace(t *testing.T) {
+	tests := []struct {
+		name          string
+		args          []string
+		wantReplace   bool
+		wantRemaining []string
+	}{
+		{
+			name:          "no --replace",
+			args:          []string{"provider-A"},
+			wantReplace:   false,
+			wantRemaining: []string{"provider-A"},
+		},
+		{
+			name:          "--replace with context",
+			args:          []string{"--replace", "provider-A", "--", "ls"},
+			wantReplace:   true,
+			wantRemaining: []string{"provider-A", "--", "ls"},
+		},
+		{
+			name:          "--replace after separator is forwarded",
+			args:          []string{"--", "--replace"},
+			wantReplace:   false,
+			wantRemaining: []string{"--", "--replace"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flags, remaining, err := ExtractFlags(tt.args)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantReplace, flags.Replace)
 			assert.Equal(t, tt.wantRemaining, remaining)
 		})
 	}
@@ -0,0 +1,32 @@
+//go:build !unix
+
+package runner
+
+import (
+	"errors"
+	"os"
+)
+
+// notifySignals are the signals Run catches while the target runs. Console
+// Ctrl-C events already reach every process attached to the console, so
+// catching os.Interrupt only keeps ccctx alive until the target exits.
+var notifySignals = []os.Signal{os.Interrupt}
+
+func forward(sig os.Signal, onTerminal bool) bool {
+	return false
+}
+
+// interrupt stops a target when RunContext's context is done. There is no
+// signal to ask it politely, so it is killed.
+func interrupt(p *os.Process) error {
+	return p.Kill()
+}
+
+func exitStatus(state *os.ProcessState) int {
+	return state.ExitCode()
+}
+
+// Replace is not supported on this platform, which has no exec.
+func (r *Runner) Replace(before func()) error {
+	return errors.New("--replace is not supported on this platform")
+}
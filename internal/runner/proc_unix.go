@@ -0,0 +1,52 @@
+//go:build unix
+
+package runner
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// notifySignals are the signals Run catches while the target runs, so that
+// they reach the target instead of killing ccctx.
+var notifySignals = []os.Signal{syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGQUIT, syscall.SIGWINCH}
+
+// forward reports whether a caught signal is passed on to the target. A
+// terminal sends SIGINT and SIGQUIT to its whole foreground process group,
+// which includes the target, so when ccctx runs on one they have reached the
+// target already; sending them again would make it see Ctrl-C twice.
+func forward(sig os.Signal, onTerminal bool) bool {
+	if onTerminal && (sig == syscall.SIGINT || sig == syscall.SIGQUIT) {
+		return false
+	}
+	return true
+}
+
+// interrupt asks a target to stop when RunContext's context is done.
+func interrupt(p *os.Process) error {
+	return p.Signal(syscall.SIGTERM)
+}
+
+// exitStatus returns the exit code of a finished process, or 128 plus the
+// signal number when a signal killed it, as shells report it.
+func exitStatus(state *os.ProcessState) int {
+	if ws, ok := state.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+		return 128 + int(ws.Signal())
+	}
+	return state.ExitCode()
+}
+
+// Replace execs the target in place of the current process, so signals and
+// the exit status need no relaying. It only returns on failure. before, if
+// not nil, is called once the target is found, just ahead of the exec.
+func (r *Runner) Replace(before func()) error {
+	path, err := exec.LookPath(r.opts.Target[0])
+	if err != nil {
+		return err
+	}
+	if before != nil {
+		before()
+	}
+	return syscall.Exec(path, r.opts.Target, r.env)
+}
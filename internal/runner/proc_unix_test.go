@@ -0,0 +1,87 @@
+//go:build unix
+
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun_ExitStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		want   int
+	}{
+		{"success", "exit 0", 0},
+		{"exit code", "exit 7", 7},
+		{"killed by SIGTERM", "kill -TERM $$", 128 + int(syscall.SIGTERM)},
+		{"killed by SIGKILL", "kill -KILL $$", 128 + int(syscall.SIGKILL)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &Runner{opts: Options{Target: []string{"/bin/sh", "-c", tt.script}}}
+			code, err := r.Run()
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, code)
+		})
+	}
+}
+
+func TestRun_ForwardsSignals(t *testing.T) {
+	for name, sig := range map[string]syscall.Signal{"TERM": syscall.SIGTERM, "HUP": syscall.SIGHUP} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			ready := filepath.Join(dir, "ready")
+			// The target reports the signal through its exit code
+			script := `trap 'exit 42' ` + name + `; touch "$1"; while :; do sleep 0.05; done`
+			r := &Runner{opts: Options{ForwardSignals: true, Target: []string{"/bin/sh", "-c", script, "sh", ready}}}
+
+			result := make(chan int, 1)
+			go func() {
+				code, err := r.Run()
+				assert.NoError(t, err)
+				result <- code
+			}()
+
+			require.Eventually(t, func() bool {
+				_, err := os.Stat(ready)
+				return err == nil
+			}, 5*time.Second, 10*time.Millisecond)
+			require.NoError(t, syscall.Kill(os.Getpid(), sig))
+
+			select {
+			case code := <-result:
+				assert.Equal(t, 42, code)
+			case <-time.After(5 * time.Second):
+				t.Fatal("target did not receive the signal")
+			}
+		})
+	}
+}
+
+func TestForward(t *testing.T) {
+	assert.True(t, forward(syscall.SIGTERM, true))
+	assert.True(t, forward(syscall.SIGWINCH, true))
+	assert.False(t, forward(syscall.SIGINT, true), "the terminal already signalled the target")
+	assert.False(t, forward(syscall.SIGQUIT, true))
+	assert.True(t, forward(syscall.SIGINT, false))
+}
+
+func TestIsTerminal(t *testing.T) {
+	pr, pw, err := os.Pipe()
+	require.NoError(t, err)
+	defer pr.Close()
+	defer pw.Close()
+
+	assert.False(t, isTerminal(pr), "a pipe is not a terminal")
+	assert.False(t, isTerminal(strings.NewReader("input")), "only files can be terminals")
+}
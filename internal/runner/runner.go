@@ -1,14 +1,22 @@
 package runner
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io"
+	"maps"
 	"net/url"
 	"os"
 	"os/exec"
+	"os/signal"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/dsdashun/ccctx/config"
+	"github.com/dsdashun/ccctx/internal/mask"
+	"golang.org/x/term"
 )
 
 type Options struct {
@@ -19,36 +27,128 @@ type Options struct {
 	HaikuModel     string
 	SonnetModel    string
 	OpusModel      string
+
+	// Environ is the environment the context is applied to, in os.Environ
+	// form. Nil means the environment of the current process.
+	Environ []string
+	// Dir is the target's working directory; empty means the current one.
+	Dir string
+	// Stdin, Stdout and Stderr are the target's standard streams. Nil means
+	// those of the current process.
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+	// ForwardSignals relays the signals the current process receives to the
+	// target while it runs, as the CLI does. Library callers usually leave
+	// their own signal handling alone.
+	ForwardSignals bool
+}
+
+// environ returns the environment the context is applied to.
+func (o Options) environ() []string {
+	if o.Environ != nil {
+		return o.Environ
+	}
+	return os.Environ()
 }
 
+// cancelGrace is how long a target has to exit after RunContext's context is
+// done and it was asked to stop, before it is killed.
+const cancelGrace = 5 * time.Second
+
 type Runner struct {
 	ctx  *config.Context
 	opts Options
 	env  []string
+	vars []EnvVar
 }
 
 func New(opts Options) (*Runner, error) {
-	ctx, err := config.GetContext(opts.ContextName)
+	cfg, err := config.LoadConfig()
 	if err != nil {
 		return nil, err
 	}
-	if ctx.BaseURL == "" {
-		return nil, fmt.Errorf("context '%s' is missing base_url", opts.ContextName)
-	}
-	if err := validateURL(ctx.BaseURL); err != nil {
-		return nil, fmt.Errorf("context '%s': %w", opts.ContextName, err)
-	}
-	if ctx.AuthToken == "" {
-		return nil, fmt.Errorf("context '%s' is missing auth_token", opts.ContextName)
+	return NewWithConfig(cfg, opts)
+}
+
+// NewWithConfig is New with the context taken from cfg instead of the
+// default config file.
+func NewWithConfig(cfg *config.Config, opts Options) (*Runner, error) {
+	ctx, err := resolveContext(cfg, opts.ContextName)
+	if err != nil {
+		return nil, err
 	}
 	if len(opts.Target) == 0 {
 		return nil, fmt.Errorf("target command is required")
 	}
 	env := buildEnv(ctx, opts)
-	return &Runner{ctx: ctx, opts: opts, env: env}, nil
+	return &Runner{ctx: ctx, opts: opts, env: env, vars: InjectedEnv(ctx, opts)}, nil
 }
 
-func validateURL(rawURL string) error {
+// EnvChanges returns the changes a runner for opts would make to the current
+// environment. Unlike New it needs no target.
+func EnvChanges(opts Options) ([]EnvChange, error) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+	ctx, err := resolveContext(cfg, opts.ContextName)
+	if err != nil {
+		return nil, err
+	}
+	return diffEnv(opts.environ(), buildEnv(ctx, opts), InjectedEnv(ctx, opts)), nil
+}
+
+func resolveContext(cfg *config.Config, name string) (*config.Context, error) {
+	ctx, err := cfg.Context(name)
+	if err != nil {
+		return nil, err
+	}
+	if err := CheckContext(name, ctx); err != nil {
+		return nil, err
+	}
+	return ctx, nil
+}
+
+// CheckContext reports the first problem that keeps ctx from being run: an
+// unknown provider, a field its provider requires left empty, or an invalid
+// base_url.
+func CheckContext(name string, ctx *config.Context) error {
+	required, err := config.RequiredFields(ctx.ProviderName())
+	if err != nil {
+		return fmt.Errorf("context '%s': %w", name, err)
+	}
+	for _, key := range required {
+		if ctx.Field(key) == "" {
+			return fmt.Errorf("context '%s' is missing %s", name, key)
+		}
+		if key == "base_url" {
+			if err := ValidateURL(ctx.BaseURL); err != nil {
+				return fmt.Errorf("context '%s': %w", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Command returns the command line the target is run with.
+func (r *Runner) Command() []string {
+	return slices.Clone(r.opts.Target)
+}
+
+// Environ returns the environment the target is run with.
+func (r *Runner) Environ() []string {
+	return slices.Clone(r.env)
+}
+
+// EnvDiff returns how the target's environment differs from the one ccctx
+// was started with.
+func (r *Runner) EnvDiff() []EnvChange {
+	return diffEnv(r.opts.environ(), r.env, r.vars)
+}
+
+// ValidateURL checks that rawURL can serve as a context's base_url.
+func ValidateURL(rawURL string) error {
 	if strings.Contains(rawURL, " ") {
 		return fmt.Errorf("invalid base_url: contains spaces")
 	}
@@ -64,76 +164,326 @@ func validateURL(rawURL string) error {
 
 // Run executes the target command. Returns (0, nil) on success, (exitCode, nil) for
 // command exit errors, (1, error) for start failures. Caller is responsible for printing errors.
+// A target killed by a signal yields 128 plus the signal number.
 func (r *Runner) Run() (int, error) {
-	cmd := exec.Command(r.opts.Target[0], r.opts.Target[1:]...)
+	return r.RunContext(context.Background())
+}
+
+// RunContext is Run with cancellation: once ctx is done the target is asked
+// to stop, killed if it has not exited within a grace period, and ctx's error
+// is returned along with its exit status.
+func (r *Runner) RunContext(ctx context.Context) (int, error) {
+	cmd := exec.CommandContext(ctx, r.opts.Target[0], r.opts.Target[1:]...)
 	cmd.Env = r.env
-	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	cmd.Dir = r.opts.Dir
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if r.opts.Stdin != nil {
+		cmd.Stdin = r.opts.Stdin
+	}
+	if r.opts.Stdout != nil {
+		cmd.Stdout = r.opts.Stdout
+	}
+	if r.opts.Stderr != nil {
+		cmd.Stderr = r.opts.Stderr
+	}
+	cmd.Cancel = func() error { return interrupt(cmd.Process) }
+	cmd.WaitDelay = cancelGrace
+
+	var signals chan os.Signal
+	if r.opts.ForwardSignals {
+		signals = make(chan os.Signal, len(notifySignals))
+		signal.Notify(signals, notifySignals...)
+		defer signal.Stop(signals)
+	}
 
-	err := cmd.Run()
+	if err := cmd.Start(); err != nil {
+		return 1, err
+	}
+
+	onTerminal := isTerminal(cmd.Stdin)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case sig := <-signals:
+				if forward(sig, onTerminal) {
+					_ = cmd.Process.Signal(sig)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	err := cmd.Wait()
+	close(done)
+	if ctx.Err() != nil {
+		code := 1
+		if cmd.ProcessState != nil {
+			code = exitStatus(cmd.ProcessState)
+		}
+		return code, ctx.Err()
+	}
 	if err != nil {
 		var exitErr *exec.ExitError
 		if errors.As(err, &exitErr) {
-			return exitErr.ExitCode(), nil
+			return exitStatus(exitErr.ProcessState), nil
 		}
 		return 1, err
 	}
 	return 0, nil
 }
 
+// isTerminal reports whether the target's stdin is a terminal, in which case
+// the terminal delivers keyboard signals to the target itself.
+func isTerminal(stdin io.Reader) bool {
+	f, ok := stdin.(*os.File)
+	return ok && term.IsTerminal(int(f.Fd()))
+}
+
+// EnvVar is a variable the runner sets in the target's environment.
+type EnvVar struct {
+	Name  string
+	Value string
+	// Source is where the value came from: the config layer that set it, such
+	// as "context.work" or "defaults", or the flag that overrode it, such as
+	// "--model".
+	Source string
+	// Ref is the secret reference the value was resolved from, if any.
+	Ref string
+	// Alias is the model alias the value was expanded from, if any.
+	Alias string
+	// Secret marks values that must be masked when displayed.
+	Secret bool
+}
+
+// providerVars lists the variables Claude Code reads to reach each provider,
+// with the config key each is set from. A key of "provider" marks a switch
+// set to 1.
+var providerVars = map[string][]struct{ name, key string }{
+	config.ProviderAnthropic: {
+		{"ANTHROPIC_BASE_URL", "base_url"},
+		{"ANTHROPIC_AUTH_TOKEN", "auth_token"},
+	},
+	config.ProviderBedrock: {
+		{"CLAUDE_CODE_USE_BEDROCK", "provider"},
+		{"AWS_REGION", "aws_region"},
+		{"AWS_PROFILE", "aws_profile"},
+	},
+	config.ProviderVertex: {
+		{"CLAUDE_CODE_USE_VERTEX", "provider"},
+		{"CLOUD_ML_REGION", "vertex_region"},
+		{"ANTHROPIC_VERTEX_PROJECT_ID", "vertex_project_id"},
+	},
+}
+
+// providerSwitches lists the variables that make Claude Code use a provider
+// other than anthropic, cleared when a context uses another one. General
+// cloud settings such as AWS_REGION are left alone, since other tools rely
+// on them too.
+var providerSwitches = map[string][]string{
+	config.ProviderBedrock: {"CLAUDE_CODE_USE_BEDROCK", "CLAUDE_CODE_SKIP_BEDROCK_AUTH"},
+	config.ProviderVertex:  {"CLAUDE_CODE_USE_VERTEX", "CLAUDE_CODE_SKIP_VERTEX_AUTH"},
+}
+
+// ProviderCleared returns the inherited variables the runner removes for
+// switching Claude Code to a provider other than ctx's, sorted.
+func ProviderCleared(ctx *config.Context) []string {
+	var names []string
+	for provider, switches := range providerSwitches {
+		if provider != ctx.ProviderName() {
+			names = append(names, switches...)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// EnvironFor returns the environment a target run for ctx without model
+// overrides would see, for talking to the context's gateway as claude would.
+func EnvironFor(ctx *config.Context) []string {
+	return buildEnv(ctx, Options{})
+}
+
 func buildEnv(ctx *config.Context, opts Options) []string {
-	env := os.Environ()
-	filtered := make([]string, 0, len(env))
+	env := opts.environ()
+	filtered := make([]string, 0, len(env)+len(ctx.Env))
 	for _, e := range env {
-		if !strings.HasPrefix(e, "ANTHROPIC_") {
+		name, _, _ := strings.Cut(e, "=")
+		if !Removes(ctx, name) {
 			filtered = append(filtered, e)
 		}
 	}
-	filtered = append(filtered, "ANTHROPIC_BASE_URL="+ctx.BaseURL)
-	filtered = append(filtered, "ANTHROPIC_AUTH_TOKEN="+ctx.AuthToken)
+	for _, v := range InjectedEnv(ctx, opts) {
+		filtered = append(filtered, v.Name+"="+v.Value)
+	}
+	return filtered
+}
 
-	// Model: opts > config > omit
-	model := opts.Model
-	if model == "" {
-		model = ctx.Model
+// Removes reports whether the runner drops the inherited variable name before
+// injecting the context's own: every ANTHROPIC_* variable, the switches of
+// other providers and the variables its own provider sets, the names listed
+// in unset, and the names the context's env table overrides.
+func Removes(ctx *config.Context, name string) bool {
+	if strings.HasPrefix(name, "ANTHROPIC_") || slices.Contains(ctx.Unset, name) {
+		return true
 	}
-	if model != "" {
-		filtered = append(filtered, "ANTHROPIC_MODEL="+model)
+	if slices.Contains(ProviderCleared(ctx), name) {
+		return true
 	}
-
-	// Haiku: opts.HaikuModel > opts.SmallFastModel > ctx.HaikuModel > ctx.SmallFastModel > omit
-	haiku := opts.HaikuModel
-	if haiku == "" {
-		haiku = opts.SmallFastModel
+	for _, v := range providerVars[ctx.ProviderName()] {
+		if v.name == name && (v.key == "provider" || ctx.Field(v.key) != "") {
+			return true
+		}
 	}
-	if haiku == "" {
-		haiku = ctx.HaikuModel
+	_, overridden := ctx.Env[name]
+	return overridden
+}
+
+// InjectedEnv returns the variables the runner sets for ctx and opts, in the
+// order they are added to the environment. Model values, from flags or the
+// config, are expanded through the context's model aliases.
+func InjectedEnv(ctx *config.Context, opts Options) []EnvVar {
+	var vars []EnvVar
+	add := func(name, value, key, flag string) {
+		// Extra env entries are set even when empty; unset fields are omitted
+		if value == "" && !strings.HasPrefix(key, "env.") {
+			return
+		}
+		v := EnvVar{Name: name, Value: value, Source: ctx.Sources[key], Ref: ctx.Refs[key]}
+		if flag != "" {
+			v.Source, v.Ref = flag, ""
+		}
+		v.Secret = v.Ref != "" || key == "auth_token" || mask.IsSecretName(name)
+		vars = append(vars, v)
 	}
-	if haiku == "" {
-		haiku = ctx.SmallFastModel
+	// addModel adds a model variable, expanding an alias through the
+	// context's models table.
+	addModel := func(name, value, key, flag string) {
+		model, alias := ctx.ExpandModel(value)
+		add(name, model, key, flag)
+		if alias != "" {
+			vars[len(vars)-1].Alias = alias
+		}
 	}
-	if haiku != "" {
-		filtered = append(filtered, "ANTHROPIC_DEFAULT_HAIKU_MODEL="+haiku)
+	// pick returns the first non-empty value, with the flag that supplied it
+	// or "" when it came from the config.
+	type candidate struct{ value, flag string }
+	pick := func(candidates ...candidate) (value, flag string) {
+		for _, c := range candidates {
+			if c.value != "" {
+				return c.value, c.flag
+			}
+		}
+		return "", ""
 	}
 
-	// Sonnet: opts > config > omit
-	sonnet := opts.SonnetModel
-	if sonnet == "" {
-		sonnet = ctx.SonnetModel
+	// Provider: only the variables of the context's own provider are set
+	for _, v := range providerVars[ctx.ProviderName()] {
+		value := ctx.Field(v.key)
+		if v.key == "provider" {
+			value = "1"
+		}
+		add(v.name, value, v.key, "")
 	}
-	if sonnet != "" {
-		filtered = append(filtered, "ANTHROPIC_DEFAULT_SONNET_MODEL="+sonnet)
+
+	// Model: opts > config > omit
+	value, flag := pick(candidate{opts.Model, "--model"}, candidate{ctx.Model, ""})
+	addModel("ANTHROPIC_MODEL", value, "model", flag)
+
+	// Haiku: opts.HaikuModel > opts.SmallFastModel > ctx.HaikuModel > ctx.SmallFastModel > omit
+	haikuKey := "haiku_model"
+	if ctx.HaikuModel == "" {
+		haikuKey = "small_fast_model"
 	}
+	value, flag = pick(
+		candidate{opts.HaikuModel, "--haiku-model"},
+		candidate{opts.SmallFastModel, "--small-fast-model"},
+		candidate{ctx.HaikuModel, ""},
+		candidate{ctx.SmallFastModel, ""},
+	)
+	addModel("ANTHROPIC_DEFAULT_HAIKU_MODEL", value, haikuKey, flag)
+
+	// Sonnet: opts > config > omit
+	value, flag = pick(candidate{opts.SonnetModel, "--sonnet-model"}, candidate{ctx.SonnetModel, ""})
+	addModel("ANTHROPIC_DEFAULT_SONNET_MODEL", value, "sonnet_model", flag)
 
 	// Opus: opts > config > omit
-	opus := opts.OpusModel
-	if opus == "" {
-		opus = ctx.OpusModel
+	value, flag = pick(candidate{opts.OpusModel, "--opus-model"}, candidate{ctx.OpusModel, ""})
+	addModel("ANTHROPIC_DEFAULT_OPUS_MODEL", value, "opus_model", flag)
+
+	// Extra env: sorted for stable output; never overrides the dedicated fields above
+	for _, name := range slices.Sorted(maps.Keys(ctx.Env)) {
+		if !slices.ContainsFunc(vars, func(v EnvVar) bool { return v.Name == name }) {
+			add(name, ctx.Env[name], "env."+name, "")
+		}
+	}
+
+	return vars
+}
+
+// Kinds of EnvChange.
+const (
+	EnvAdded      = "added"
+	EnvOverridden = "overridden"
+	EnvRemoved    = "removed"
+)
+
+// EnvChange is a variable the runner adds, overrides or removes.
+type EnvChange struct {
+	Name string
+	Kind string
+	// Old is the inherited value, New the value the target sees.
+	Old, New string
+	// Source is where New came from, as in EnvVar.
+	Source string
+	Secret bool
+}
+
+// diffEnv compares environments in os.Environ form. Added and overridden
+// variables are listed in the order of after, followed by the removed ones
+// sorted by name.
+func diffEnv(before, after []string, vars []EnvVar) []EnvChange {
+	inherited := make(map[string]string, len(before))
+	for _, e := range before {
+		name, value, _ := strings.Cut(e, "=")
+		inherited[name] = value
 	}
-	if opus != "" {
-		filtered = append(filtered, "ANTHROPIC_DEFAULT_OPUS_MODEL="+opus)
+	injected := make(map[string]EnvVar, len(vars))
+	for _, v := range vars {
+		injected[v.Name] = v
 	}
 
-	return filtered
+	var changes []EnvChange
+	kept := make(map[string]bool, len(after))
+	for _, e := range after {
+		name, value, _ := strings.Cut(e, "=")
+		kept[name] = true
+		old, existed := inherited[name]
+		if existed && old == value {
+			continue
+		}
+		change := EnvChange{
+			Name:   name,
+			Kind:   EnvAdded,
+			Old:    old,
+			New:    value,
+			Source: injected[name].Source,
+			Secret: injected[name].Secret || mask.IsSecretName(name),
+		}
+		if existed {
+			change.Kind = EnvOverridden
+		}
+		changes = append(changes, change)
+	}
+	for _, name := range slices.Sorted(maps.Keys(inherited)) {
+		if !kept[name] {
+			changes = append(changes, EnvChange{
+				Name:   name,
+				Kind:   EnvRemoved,
+				Old:    inherited[name],
+				Secret: mask.IsSecretName(name),
+			})
+		}
+	}
+	return changes
 }
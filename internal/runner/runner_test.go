@@ -369,6 +369,327 @@ func TestBuildEnv_SmallFastModelNotInjected(t *testing.T) {
 	}
 }
 
+func TestBuildEnv_ExtraEnv(t *testing.T) {
+	t.Setenv("HTTPS_PROXY", "http://old-proxy:3128")
+	t.Setenv("DISABLE_TELEMETRY", "0")
+	t.Setenv("NODE_EXTRA_CA_CERTS", "/etc/old-ca.pem")
+	t.Setenv("CCCTX_TEST_KEEP", "kept")
+
+	tests := []struct {
+		name    string
+		env     map[string]string
+		unset   []string
+		model   string
+		want    []string
+		wantNot []string
+	}{
+		{
+			name: "extra variables are injected",
+			env: map[string]string{
+				"ANTHROPIC_CUSTOM_HEADERS":      "X-Team: core",
+				"CLAUDE_CODE_MAX_OUTPUT_TOKENS": "32000",
+			},
+			want: []string{
+				"ANTHROPIC_CUSTOM_HEADERS=X-Team: core",
+				"CLAUDE_CODE_MAX_OUTPUT_TOKENS=32000",
+				"CCCTX_TEST_KEEP=kept",
+			},
+		},
+		{
+			name: "extra variable replaces inherited value",
+			env:  map[string]string{"HTTPS_PROXY": "http://new-proxy:3128"},
+			want: []string{"HTTPS_PROXY=http://new-proxy:3128"},
+			wantNot: []string{
+				"HTTPS_PROXY=http://old-proxy:3128",
+			},
+		},
+		{
+			name:  "unset removes inherited variables",
+			unset: []string{"NODE_EXTRA_CA_CERTS", "DISABLE_TELEMETRY"},
+			want:  []string{"CCCTX_TEST_KEEP=kept"},
+			wantNot: []string{
+				"NODE_EXTRA_CA_CERTS=",
+				"DISABLE_TELEMETRY=",
+			},
+		},
+		{
+			name:    "env wins over unset for the same variable",
+			env:     map[string]string{"DISABLE_TELEMETRY": "1"},
+			unset:   []string{"DISABLE_TELEMETRY"},
+			want:    []string{"DISABLE_TELEMETRY=1"},
+			wantNot: []string{"DISABLE_TELEMETRY=0"},
+		},
+		{
+			name:    "dedicated fields win over extra env",
+			env:     map[string]string{"ANTHROPIC_MODEL": "env-model", "ANTHROPIC_BASE_URL": "https://other.example.com"},
+			model:   "field-model",
+			want:    []string{"ANTHROPIC_MODEL=field-model", "ANTHROPIC_BASE_URL=https://api.example.com"},
+			wantNot: []string{"ANTHROPIC_MODEL=env-model", "ANTHROPIC_BASE_URL=https://other.example.com"},
+		},
+		{
+			name: "extra env fills a dedicated variable left unset",
+			env:  map[string]string{"ANTHROPIC_MODEL": "env-model"},
+			want: []string{"ANTHROPIC_MODEL=env-model"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := &config.Context{
+				BaseURL:   "https://api.example.com",
+				AuthToken: "test-token",
+				Model:     tt.model,
+				Env:       tt.env,
+				Unset:     tt.unset,
+			}
+
+			env := buildEnv(ctx, Options{})
+
+			for _, want := range tt.want {
+				assertEnvContains(t, env, want)
+			}
+			for _, e := range env {
+				for _, prefix := range tt.wantNot {
+					assert.False(t, strings.HasPrefix(e, prefix), "unexpected %q in env", e)
+				}
+			}
+			seen := make(map[string]bool, len(env))
+			for _, e := range env {
+				name, _, _ := strings.Cut(e, "=")
+				assert.False(t, seen[name], "%s injected more than once", name)
+				seen[name] = true
+			}
+		})
+	}
+}
+
+func TestInjectedEnv(t *testing.T) {
+	ctx := &config.Context{
+		BaseURL:    "https://api.example.com",
+		AuthToken:  "sk-ant-0123456789abcdef",
+		Model:      "config-model",
+		HaikuModel: "config-haiku",
+		Env: map[string]string{
+			"ANTHROPIC_MODEL":   "ignored",
+			"HTTPS_PROXY":       "http://proxy:3128",
+			"GATEWAY_API_KEY":   "key-from-vault",
+			"DISABLE_TELEMETRY": "",
+		},
+		Sources: map[string]string{
+			"base_url":              "defaults",
+			"auth_token":            "context.work",
+			"model":                 "context.base",
+			"haiku_model":           "context.work",
+			"env.HTTPS_PROXY":       "context.work",
+			"env.GATEWAY_API_KEY":   "context.work",
+			"env.DISABLE_TELEMETRY": "context.work",
+		},
+		Refs: map[string]string{
+			"auth_token":          "env:WORK_TOKEN",
+			"env.GATEWAY_API_KEY": "vault:gateway",
+		},
+	}
+	opts := Options{OpusModel: "flag-opus", SmallFastModel: "flag-haiku"}
+
+	want := []EnvVar{
+		{Name: "ANTHROPIC_BASE_URL", Value: "https://api.example.com", Source: "defaults"},
+		{Name: "ANTHROPIC_AUTH_TOKEN", Value: "sk-ant-0123456789abcdef", Source: "context.work", Ref: "env:WORK_TOKEN", Secret: true},
+		{Name: "ANTHROPIC_MODEL", Value: "config-model", Source: "context.base"},
+		{Name: "ANTHROPIC_DEFAULT_HAIKU_MODEL", Value: "flag-haiku", Source: "--small-fast-model"},
+		{Name: "ANTHROPIC_DEFAULT_OPUS_MODEL", Value: "flag-opus", Source: "--opus-model"},
+		{Name: "DISABLE_TELEMETRY", Value: "", Source: "context.work"},
+		{Name: "GATEWAY_API_KEY", Value: "key-from-vault", Source: "context.work", Ref: "vault:gateway", Secret: true},
+		{Name: "HTTPS_PROXY", Value: "http://proxy:3128", Source: "context.work"},
+	}
+	assert.Equal(t, want, InjectedEnv(ctx, opts))
+}
+
+func TestInjectedEnv_ModelAliases(t *testing.T) {
+	ctx := &config.Context{
+		BaseURL:     "https://api.example.com",
+		AuthToken:   "work-token",
+		Model:       "sonnet",
+		SonnetModel: "claude-sonnet-4-6",
+		Models:      map[string]string{"opus": "claude-opus-4-7", "sonnet": "claude-sonnet-4-6"},
+		Sources: map[string]string{
+			"base_url":     "context.work",
+			"auth_token":   "context.work",
+			"model":        "context.work",
+			"sonnet_model": "context.work",
+		},
+	}
+	opts := Options{OpusModel: "OPUS", HaikuModel: "claude-haiku-4-5"}
+
+	want := []EnvVar{
+		{Name: "ANTHROPIC_BASE_URL", Value: "https://api.example.com", Source: "context.work"},
+		{Name: "ANTHROPIC_AUTH_TOKEN", Value: "work-token", Source: "context.work", Secret: true},
+		{Name: "ANTHROPIC_MODEL", Value: "claude-sonnet-4-6", Source: "context.work", Alias: "sonnet"},
+		{Name: "ANTHROPIC_DEFAULT_HAIKU_MODEL", Value: "claude-haiku-4-5", Source: "--haiku-model"},
+		{Name: "ANTHROPIC_DEFAULT_SONNET_MODEL", Value: "claude-sonnet-4-6", Source: "context.work"},
+		{Name: "ANTHROPIC_DEFAULT_OPUS_MODEL", Value: "claude-opus-4-7", Source: "--opus-model", Alias: "opus"},
+	}
+	assert.Equal(t, want, InjectedEnv(ctx, opts))
+}
+
+func TestDiffEnv(t *testing.T) {
+	before := []string{
+		"PATH=/usr/bin",
+		"ANTHROPIC_API_KEY=old-key",
+		"ANTHROPIC_MODEL=old-model",
+		"HTTP_PROXY=http://old-proxy",
+		"HOME=/home/user",
+	}
+	after := []string{
+		"PATH=/usr/bin",
+		"HOME=/home/user",
+		"ANTHROPIC_BASE_URL=https://api.example.com",
+		"ANTHROPIC_AUTH_TOKEN=secret-token",
+		"ANTHROPIC_MODEL=new-model",
+	}
+	vars := []EnvVar{
+		{Name: "ANTHROPIC_BASE_URL", Value: "https://api.example.com", Source: "context.work"},
+		{Name: "ANTHROPIC_AUTH_TOKEN", Value: "secret-token", Source: "context.work", Secret: true},
+		{Name: "ANTHROPIC_MODEL", Value: "new-model", Source: "--model"},
+	}
+
+	want := []EnvChange{
+		{Name: "ANTHROPIC_BASE_URL", Kind: EnvAdded, New: "https://api.example.com", Source: "context.work"},
+		{Name: "ANTHROPIC_AUTH_TOKEN", Kind: EnvAdded, New: "secret-token", Source: "context.work", Secret: true},
+		{Name: "ANTHROPIC_MODEL", Kind: EnvOverridden, Old: "old-model", New: "new-model", Source: "--model"},
+		{Name: "ANTHROPIC_API_KEY", Kind: EnvRemoved, Old: "old-key", Secret: true},
+		{Name: "HTTP_PROXY", Kind: EnvRemoved, Old: "http://old-proxy"},
+	}
+	assert.Equal(t, want, diffEnv(before, after, vars))
+}
+
+func TestBuildEnv_Providers(t *testing.T) {
+	environ := []string{
+		"PATH=/usr/bin",
+		"ANTHROPIC_BASE_URL=https://old.example.com",
+		"CLAUDE_CODE_USE_BEDROCK=1",
+		"CLAUDE_CODE_SKIP_BEDROCK_AUTH=1",
+		"AWS_REGION=eu-west-1",
+		"AWS_PROFILE=old",
+		"CLAUDE_CODE_USE_VERTEX=1",
+		"CLOUD_ML_REGION=us-east5",
+		"ANTHROPIC_VERTEX_PROJECT_ID=old-project",
+	}
+
+	tests := []struct {
+		name string
+		ctx  *config.Context
+		want []string
+	}{
+		{
+			name: "anthropic clears the bedrock and vertex switches only",
+			ctx:  &config.Context{BaseURL: "https://api.example.com", AuthToken: "token", Model: "opus"},
+			want: []string{
+				"PATH=/usr/bin",
+				"AWS_REGION=eu-west-1",
+				"AWS_PROFILE=old",
+				"CLOUD_ML_REGION=us-east5",
+				"ANTHROPIC_BASE_URL=https://api.example.com",
+				"ANTHROPIC_AUTH_TOKEN=token",
+				"ANTHROPIC_MODEL=opus",
+			},
+		},
+		{
+			name: "bedrock keeps an inherited profile",
+			ctx: &config.Context{
+				Provider:  config.ProviderBedrock,
+				BaseURL:   "https://ignored.example.com",
+				AuthToken: "ignored",
+				AWSRegion: "us-east-1",
+				Model:     "us.anthropic.claude-opus-4-7",
+			},
+			want: []string{
+				"PATH=/usr/bin",
+				"CLAUDE_CODE_SKIP_BEDROCK_AUTH=1",
+				"AWS_PROFILE=old",
+				"CLOUD_ML_REGION=us-east5",
+				"CLAUDE_CODE_USE_BEDROCK=1",
+				"AWS_REGION=us-east-1",
+				"ANTHROPIC_MODEL=us.anthropic.claude-opus-4-7",
+			},
+		},
+		{
+			name: "vertex",
+			ctx: &config.Context{
+				Provider:        config.ProviderVertex,
+				VertexRegion:    "europe-west1",
+				VertexProjectID: "my-project",
+			},
+			want: []string{
+				"PATH=/usr/bin",
+				"AWS_REGION=eu-west-1",
+				"AWS_PROFILE=old",
+				"CLAUDE_CODE_USE_VERTEX=1",
+				"CLOUD_ML_REGION=europe-west1",
+				"ANTHROPIC_VERTEX_PROJECT_ID=my-project",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, buildEnv(tt.ctx, Options{Environ: environ}))
+		})
+	}
+}
+
+func TestCheckContext(t *testing.T) {
+	tests := []struct {
+		name    string
+		ctx     *config.Context
+		wantErr string
+	}{
+		{
+			name: "anthropic",
+			ctx:  &config.Context{BaseURL: "https://api.example.com", AuthToken: "token"},
+		},
+		{
+			name:    "anthropic without a token",
+			ctx:     &config.Context{BaseURL: "https://api.example.com"},
+			wantErr: "context 'work' is missing auth_token",
+		},
+		{
+			name:    "anthropic with an invalid base url",
+			ctx:     &config.Context{Provider: config.ProviderAnthropic, BaseURL: "api.example.com", AuthToken: "token"},
+			wantErr: "context 'work': invalid base_url: missing scheme (e.g., https://)",
+		},
+		{
+			name: "bedrock needs no base url or token",
+			ctx:  &config.Context{Provider: config.ProviderBedrock, AWSRegion: "us-east-1"},
+		},
+		{
+			name:    "bedrock without a region",
+			ctx:     &config.Context{Provider: config.ProviderBedrock, AWSProfile: "work"},
+			wantErr: "context 'work' is missing aws_region",
+		},
+		{
+			name:    "vertex without a project",
+			ctx:     &config.Context{Provider: config.ProviderVertex, VertexRegion: "us-east5"},
+			wantErr: "context 'work' is missing vertex_project_id",
+		},
+		{
+			name:    "unknown provider",
+			ctx:     &config.Context{Provider: "azure", BaseURL: "https://api.example.com", AuthToken: "token"},
+			wantErr: "context 'work': unknown provider 'azure': use anthropic, bedrock or vertex",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckContext("work", tt.ctx)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
 func TestValidateURL(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -410,7 +731,7 @@ func TestValidateURL(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := validateURL(tt.rawURL)
+			err := ValidateURL(tt.rawURL)
 			if tt.wantErr != "" {
 				require.Error(t, err)
 				assert.Contains(t, err.Error(), tt.wantErr)
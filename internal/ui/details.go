@@ -0,0 +1,77 @@
+package ui
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/rivo/tview"
+)
+
+// detailsLines is the number of lines details renders, so the pane keeps its
+// height while the highlight moves.
+const detailsLines = 9
+
+// details renders the details pane for an entry, with fields it does not set
+// shown as "-".
+func details(e Entry, now time.Time) string {
+	rows := []struct{ label, value string }{
+		{"Host", host(e.BaseURL)},
+		{"Model", e.Model},
+		{"Haiku", e.HaikuModel},
+		{"Sonnet", e.SonnetModel},
+		{"Opus", e.OpusModel},
+		{"Token", e.Token},
+		{"Last used", Ago(e.LastUsed, now)},
+		{"Tags", strings.Join(e.Tags, ", ")},
+		{"About", e.Description},
+	}
+	var b strings.Builder
+	for i, row := range rows {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		value := row.value
+		if value == "" {
+			value = "-"
+		}
+		fmt.Fprintf(&b, "[gray]%-10s[-] %s", row.label+":", tview.Escape(value))
+	}
+	return b.String()
+}
+
+// host returns the host of a base URL, or the URL itself when it does not
+// parse into one.
+func host(baseURL string) string {
+	if u, err := url.Parse(baseURL); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return baseURL
+}
+
+// Ago describes t relative to now, e.g. "3 hours ago", or "never" when t is
+// zero.
+func Ago(t, now time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+	plural := func(n int, unit string) string {
+		if n == 1 {
+			return fmt.Sprintf("1 %s ago", unit)
+		}
+		return fmt.Sprintf("%d %ss ago", n, unit)
+	}
+	switch d := now.Sub(t); {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return plural(int(d/time.Minute), "minute")
+	case d < 24*time.Hour:
+		return plural(int(d/time.Hour), "hour")
+	case d < 30*24*time.Hour:
+		return plural(int(d/(24*time.Hour)), "day")
+	default:
+		return t.Local().Format("2006-01-02")
+	}
+}
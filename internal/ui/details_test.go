@@ -0,0 +1,63 @@
+package ui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetails(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	entry := Entry{
+		Name:        "work",
+		Description: "Company [gateway]",
+		Tags:        []string{"work", "eu"},
+		BaseURL:     "https://gateway.example.com:8443/v1",
+		Model:       "claude-sonnet-4-6",
+		OpusModel:   "claude-opus-4-7",
+		Token:       "sk-a****wxyz",
+		LastUsed:    now.Add(-3 * time.Hour),
+	}
+
+	want := "[gray]Host:     [-] gateway.example.com:8443\n" +
+		"[gray]Model:    [-] claude-sonnet-4-6\n" +
+		"[gray]Haiku:    [-] -\n" +
+		"[gray]Sonnet:   [-] -\n" +
+		"[gray]Opus:     [-] claude-opus-4-7\n" +
+		"[gray]Token:    [-] sk-a****wxyz\n" +
+		"[gray]Last used:[-] 3 hours ago\n" +
+		"[gray]Tags:     [-] work, eu\n" +
+		"[gray]About:    [-] Company [gateway[]"
+	got := details(entry, now)
+	assert.Equal(t, want, got)
+}
+
+func TestHost(t *testing.T) {
+	assert.Equal(t, "api.anthropic.com", host("https://api.anthropic.com"))
+	assert.Equal(t, "localhost:8080", host("http://localhost:8080/proxy"))
+	assert.Equal(t, "not a url", host("not a url"))
+	assert.Equal(t, "", host(""))
+}
+
+func TestAgo(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		ago  time.Duration
+		want string
+	}{
+		{10 * time.Second, "just now"},
+		{time.Minute, "1 minute ago"},
+		{45 * time.Minute, "45 minutes ago"},
+		{time.Hour, "1 hour ago"},
+		{23 * time.Hour, "23 hours ago"},
+		{48 * time.Hour, "2 days ago"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, Ago(now.Add(-tt.ago), now))
+	}
+	assert.Equal(t, "never", Ago(time.Time{}, now))
+
+	old := now.Add(-90 * 24 * time.Hour)
+	assert.Equal(t, old.Local().Format("2006-01-02"), Ago(old, now))
+}
@@ -0,0 +1,162 @@
+package ui
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/rivo/tview"
+)
+
+// Scoring weights for fuzzyMatch. Consecutive runs and word starts are what
+// people type when they abbreviate a name, so they count most.
+const (
+	scoreMatch       = 1
+	scoreConsecutive = 5
+	scoreWordStart   = 3
+	// scoreName prefers a match in the name over one in a tag or description.
+	scoreName = 10
+)
+
+// fuzzyMatch reports whether the runes of pattern appear in text in order,
+// ignoring case, and returns a score and the rune positions of text that
+// matched. An empty pattern matches everything with score 0.
+func fuzzyMatch(pattern, text string) (score int, positions []int, ok bool) {
+	want := []rune(strings.ToLower(pattern))
+	if len(want) == 0 {
+		return 0, nil, true
+	}
+	runes := []rune(text)
+	j := 0
+	for i, r := range runes {
+		if j == len(want) {
+			break
+		}
+		if unicode.ToLower(r) != want[j] {
+			continue
+		}
+		score += scoreMatch
+		if len(positions) > 0 && positions[len(positions)-1] == i-1 {
+			score += scoreConsecutive
+		}
+		if i == 0 || strings.ContainsRune(" -_./:", runes[i-1]) {
+			score += scoreWordStart
+		}
+		positions = append(positions, i)
+		j++
+	}
+	if j < len(want) {
+		return 0, nil, false
+	}
+	return score, positions, true
+}
+
+// Where a match was found within an Entry.
+const (
+	fieldName = iota
+	fieldTag
+	fieldDescription
+)
+
+// match is an Entry that passed the filter, with the positions to highlight in
+// the field that matched best.
+type match struct {
+	entry     Entry
+	score     int
+	field     int
+	tag       int // index into entry.Tags when field is fieldTag
+	positions []int
+}
+
+// filterEntries returns the entries matching query, best first. Entries that
+// score the same keep their original order.
+func filterEntries(entries []Entry, query string) []match {
+	query = strings.TrimSpace(query)
+	var matches []match
+	for _, e := range entries {
+		best := match{entry: e, score: -1}
+		if score, positions, ok := fuzzyMatch(query, e.Name); ok {
+			best = match{entry: e, score: score + scoreName, field: fieldName, positions: positions}
+		}
+		for i, tag := range e.Tags {
+			if score, positions, ok := fuzzyMatch(query, tag); ok && score > best.score {
+				best = match{entry: e, score: score, field: fieldTag, tag: i, positions: positions}
+			}
+		}
+		if score, positions, ok := fuzzyMatch(query, e.Description); ok && score > best.score {
+			best = match{entry: e, score: score, field: fieldDescription, positions: positions}
+		}
+		if best.score >= 0 {
+			matches = append(matches, best)
+		}
+	}
+	if query != "" {
+		slices.SortStableFunc(matches, func(a, b match) int {
+			return cmp.Compare(b.score, a.score)
+		})
+	}
+	return matches
+}
+
+// text renders the match as a list item: the name, then its tags and
+// description dimmed, with the matched characters highlighted.
+func (m match) text() string {
+	var b strings.Builder
+	b.WriteString(highlight(m.entry.Name, m.positionsIn(fieldName, 0), "-"))
+	for i, tag := range m.entry.Tags {
+		b.WriteString("  [gray]#")
+		b.WriteString(highlight(tag, m.positionsIn(fieldTag, i), "gray"))
+		b.WriteString("[-]")
+	}
+	if m.entry.Description != "" {
+		b.WriteString("  [gray]")
+		b.WriteString(highlight(m.entry.Description, m.positionsIn(fieldDescription, 0), "gray"))
+		b.WriteString("[-]")
+	}
+	return b.String()
+}
+
+func (m match) positionsIn(field, tag int) []int {
+	if m.field != field || (field == fieldTag && m.tag != tag) {
+		return nil
+	}
+	return m.positions
+}
+
+// highlight escapes text for tview and colors the runes at positions, going
+// back to color base after each run of them.
+func highlight(text string, positions []int, base string) string {
+	if len(positions) == 0 {
+		return tview.Escape(text)
+	}
+	var b strings.Builder
+	var segment []rune
+	inMatch := false
+	flush := func() {
+		if len(segment) == 0 {
+			return
+		}
+		if inMatch {
+			b.WriteString("[yellow::b]" + tview.Escape(string(segment)) + "[" + base + "::-]")
+		} else {
+			b.WriteString(tview.Escape(string(segment)))
+		}
+		segment = segment[:0]
+	}
+	for i, r := range []rune(text) {
+		matched := slices.Contains(positions, i)
+		if matched != inMatch {
+			flush()
+			inMatch = matched
+		}
+		segment = append(segment, r)
+	}
+	flush()
+	return b.String()
+}
+
+// plainWidth is the width of a match's text without color tags.
+func (m match) plainWidth() int {
+	return tview.TaggedStringWidth(m.text())
+}
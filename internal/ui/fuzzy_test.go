@@ -0,0 +1,111 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFuzzyMatch(t *testing.T) {
+	tests := []struct {
+		name          string
+		pattern       string
+		text          string
+		wantOK        bool
+		wantPositions []int
+	}{
+		{"empty pattern", "", "work", true, nil},
+		{"prefix", "wo", "work", true, []int{0, 1}},
+		{"subsequence", "wk", "work", true, []int{0, 3}},
+		{"case insensitive", "WK", "Work", true, []int{0, 3}},
+		{"out of order", "kw", "work", false, nil},
+		{"longer than text", "works", "work", false, nil},
+		{"multibyte", "ü", "münchen", true, []int{1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, positions, ok := fuzzyMatch(tt.pattern, tt.text)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.wantPositions, positions)
+		})
+	}
+}
+
+func TestFuzzyMatch_Scoring(t *testing.T) {
+	consecutive, _, _ := fuzzyMatch("pro", "prod")
+	scattered, _, _ := fuzzyMatch("pro", "p-r-o")
+	assert.Greater(t, consecutive, scattered)
+
+	wordStart, _, _ := fuzzyMatch("b", "team-b")
+	inner, _, _ := fuzzyMatch("b", "teamb")
+	assert.Greater(t, wordStart, inner)
+}
+
+func TestFilterEntries(t *testing.T) {
+	entries := []Entry{
+		{Name: "alpha", Description: "Personal account"},
+		{Name: "prod", Tags: []string{"work"}},
+		{Name: "staging", Description: "Work proxy"},
+		{Name: "work"},
+	}
+
+	names := func(matches []match) []string {
+		var names []string
+		for _, m := range matches {
+			names = append(names, m.entry.Name)
+		}
+		return names
+	}
+
+	assert.Equal(t, []string{"alpha", "prod", "staging", "work"}, names(filterEntries(entries, "")))
+	assert.Equal(t, []string{"alpha", "prod", "staging", "work"}, names(filterEntries(entries, "  ")))
+	// name matches rank before tag and description matches
+	assert.Equal(t, []string{"work", "prod", "staging"}, names(filterEntries(entries, "work")))
+	assert.Equal(t, []string{"alpha"}, names(filterEntries(entries, "personal")))
+	assert.Empty(t, filterEntries(entries, "xyz"))
+
+	matches := filterEntries(entries, "work")
+	assert.Equal(t, fieldTag, matches[1].field)
+	assert.Equal(t, fieldDescription, matches[2].field)
+}
+
+func TestMatchText(t *testing.T) {
+	tests := []struct {
+		name  string
+		entry Entry
+		query string
+		want  string
+	}{
+		{
+			name:  "no filter",
+			entry: Entry{Name: "work"},
+			want:  "work",
+		},
+		{
+			name:  "name highlighted",
+			entry: Entry{Name: "work"},
+			query: "wk",
+			want:  "[yellow::b]w[-::-]or[yellow::b]k[-::-]",
+		},
+		{
+			name:  "tags and description dimmed",
+			entry: Entry{Name: "prod", Description: "Main", Tags: []string{"work"}},
+			query: "wo",
+			want:  "prod  [gray]#[yellow::b]wo[gray::-]rk[-]  [gray]Main[-]",
+		},
+		{
+			name:  "brackets escaped",
+			entry: Entry{Name: "a", Description: "[red]"},
+			want:  "a  [gray][red[][-]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matches := filterEntries([]Entry{tt.entry}, tt.query)
+			assert.Len(t, matches, 1)
+			assert.Equal(t, tt.want, matches[0].text())
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package ui
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// PromptContext asks for a context with a numbered list written to out, for
+// terminals the full selector cannot run in. The answer is read from in as a
+// number or a name; an empty answer or end of input cancels.
+func PromptContext(entries []Entry, in io.Reader, out io.Writer) (string, error) {
+	return prompt(entries, contextKind, in, out)
+}
+
+// PromptModel is PromptContext for models.
+func PromptModel(entries []Entry, in io.Reader, out io.Writer) (string, error) {
+	return prompt(entries, modelKind, in, out)
+}
+
+func prompt(entries []Entry, kind selectorKind, in io.Reader, out io.Writer) (string, error) {
+	if len(entries) == 0 {
+		return "", fmt.Errorf("no %ss found", kind.noun)
+	}
+
+	width := len(strconv.Itoa(len(entries)))
+	fmt.Fprintf(out, "Select a %s:\n", kind.noun)
+	for i, e := range entries {
+		line := fmt.Sprintf("  %*d) %s", width, i+1, e.Name)
+		if e.Description != "" {
+			line += "  " + e.Description
+		}
+		fmt.Fprintln(out, line)
+	}
+
+	for {
+		fmt.Fprint(out, "Enter a number or name (empty to cancel): ")
+		line, err := readLine(in)
+		if err != nil {
+			fmt.Fprintln(out)
+			if errors.Is(err, io.EOF) {
+				return "", ErrCancelled
+			}
+			return "", err
+		}
+		answer := strings.TrimSpace(line)
+		if answer == "" {
+			return "", ErrCancelled
+		}
+		if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(entries) {
+			return entries[n-1].Name, nil
+		}
+		if i := slices.IndexFunc(entries, func(e Entry) bool { return e.Name == answer }); i >= 0 {
+			return entries[i].Name, nil
+		}
+		fmt.Fprintf(out, "Invalid choice '%s'.\n", answer)
+	}
+}
+
+// readLine reads up to and including the next newline one byte at a time, so
+// input after the answer stays unread for the command being launched. A last
+// line without a newline is returned as is; io.EOF means there was none.
+func readLine(in io.Reader) (string, error) {
+	var line []byte
+	b := make([]byte, 1)
+	for {
+		n, err := in.Read(b)
+		if n == 1 {
+			if b[0] == '\n' {
+				return string(line), nil
+			}
+			line = append(line, b[0])
+		}
+		if err != nil {
+			if errors.Is(err, io.EOF) && len(line) > 0 {
+				return string(line), nil
+			}
+			return "", err
+		}
+	}
+}
+
+// RunPicker lets an external program such as fzf choose a context. command is
+// run by the shell, like cmd: secret references, so it may quote arguments.
+// The picker receives the context names on stdin, one per line, and prints
+// the chosen one. The picker exiting with an error or printing nothing
+// cancels; a command the shell cannot find or run is an error.
+func RunPicker(command string, entries []Entry) (string, error) {
+	if len(entries) == 0 {
+		return "", fmt.Errorf("no contexts found")
+	}
+	if strings.TrimSpace(command) == "" {
+		return "", fmt.Errorf("picker command is empty")
+	}
+
+	var names bytes.Buffer
+	for _, e := range entries {
+		fmt.Fprintln(&names, e.Name)
+	}
+	var chosen bytes.Buffer
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+	cmd.Stdin = &names
+	cmd.Stdout = &chosen
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return "", fmt.Errorf("failed to run picker '%s': %w", command, err)
+		}
+		// Shells exit with 126 and 127 when the command cannot be run or found
+		if code := exitErr.ExitCode(); code == 126 || code == 127 {
+			return "", fmt.Errorf("failed to run picker '%s': exit status %d", command, code)
+		}
+		return "", ErrCancelled
+	}
+
+	name := strings.TrimSpace(chosen.String())
+	if name == "" {
+		return "", ErrCancelled
+	}
+	if !slices.ContainsFunc(entries, func(e Entry) bool { return e.Name == name }) {
+		return "", fmt.Errorf("picker returned unknown context '%s'", name)
+	}
+	return name, nil
+}
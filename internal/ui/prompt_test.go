@@ -0,0 +1,143 @@
+package ui
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPromptContext(t *testing.T) {
+	entries := []Entry{{Name: "personal"}, {Name: "work", Description: "Company gateway"}}
+
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr error
+		wantOut string
+	}{
+		{
+			name:  "by number",
+			input: "2\n",
+			want:  "work",
+		},
+		{
+			name:  "by name without newline",
+			input: "personal",
+			want:  "personal",
+		},
+		{
+			name:    "invalid answers are asked again",
+			input:   "3\nnope\n1\n",
+			want:    "personal",
+			wantOut: "Invalid choice '3'.\n",
+		},
+		{
+			name:    "empty answer cancels",
+			input:   "\n2\n",
+			wantErr: ErrCancelled,
+		},
+		{
+			name:    "end of input cancels",
+			input:   "",
+			wantErr: ErrCancelled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			got, err := PromptContext(entries, strings.NewReader(tt.input), &out)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+			assert.True(t, strings.HasPrefix(out.String(), "Select a context:\n  1) personal\n  2) work  Company gateway\n"), out.String())
+			assert.Contains(t, out.String(), tt.wantOut)
+		})
+	}
+}
+
+func TestPromptContext_LeavesRemainingInput(t *testing.T) {
+	in := strings.NewReader("1\nfor the command\n")
+	got, err := PromptContext([]Entry{{Name: "work"}}, in, &bytes.Buffer{})
+	require.NoError(t, err)
+	assert.Equal(t, "work", got)
+
+	rest := new(strings.Builder)
+	_, err = in.WriteTo(rest)
+	require.NoError(t, err)
+	assert.Equal(t, "for the command\n", rest.String())
+}
+
+func TestRunPicker(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("picker scripts need sh")
+	}
+	entries := []Entry{{Name: "personal"}, {Name: "work"}}
+	dir := t.TempDir()
+	script := func(name, body string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\n"+body), 0755))
+		return path
+	}
+
+	tests := []struct {
+		name    string
+		command string
+		want    string
+		wantErr string
+	}{
+		{
+			name:    "picks from the names on stdin",
+			command: script("last", "tail -n 1\n") + " --ignored-arg",
+			want:    "work",
+		},
+		{
+			name:    "quoted arguments",
+			command: script("quoted", `[ $# -eq 2 ] && [ "$1" = --prompt ] && [ "$2" = "ctx> " ] && echo work`+"\n") + ` --prompt "ctx> "`,
+			want:    "work",
+		},
+		{
+			name:    "non-zero exit cancels",
+			command: script("abort", "exit 130\n"),
+			wantErr: ErrCancelled.Error(),
+		},
+		{
+			name:    "no output cancels",
+			command: script("silent", "cat >/dev/null\n"),
+			wantErr: ErrCancelled.Error(),
+		},
+		{
+			name:    "unknown context",
+			command: script("bogus", "echo bogus\n"),
+			wantErr: "picker returned unknown context 'bogus'",
+		},
+		{
+			name:    "missing picker",
+			command: filepath.Join(dir, "missing"),
+			wantErr: "failed to run picker",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RunPicker(tt.command, entries)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"runtime/debug"
+	"time"
 	"unicode/utf8"
 
 	"github.com/gdamore/tcell/v2"
@@ -12,20 +13,69 @@ import (
 
 var ErrCancelled = errors.New("operation cancelled")
 
-func RunContextSelector(contexts []string) (string, error) {
-	if len(contexts) == 0 {
+// Entry is a context offered by the selector. Description and tags are shown
+// next to the name and searched by the filter; the other fields only appear in
+// the details pane of the highlighted context.
+type Entry struct {
+	Name        string
+	Description string
+	Tags        []string
+	BaseURL     string
+	Model       string
+	HaikuModel  string
+	SonnetModel string
+	OpusModel   string
+	// Token is shown as is, so callers must mask it or pass a secret
+	// reference instead.
+	Token string
+	// LastUsed is zero for contexts never run.
+	LastUsed time.Time
+}
+
+// selectorKind adapts the selector to what it offers.
+type selectorKind struct {
+	title string
+	// noun names an entry in messages, e.g. "context"
+	noun string
+	// details shows the details pane, which describes contexts
+	details bool
+}
+
+var (
+	contextKind = selectorKind{"Select a context to run with (/ to filter, ESC to cancel)", "context", true}
+	modelKind   = selectorKind{"Select a model (/ to filter, ESC to cancel)", "model", false}
+)
+
+func RunContextSelector(entries []Entry) (string, error) {
+	if len(entries) == 0 {
 		return "", fmt.Errorf("no contexts found")
 	}
 
-	return runTviewSelector(contexts)
+	return runTviewSelector(entries, contextKind, nil)
 }
 
-func runTviewSelector(contexts []string) (result string, err error) {
+// RunModelSelector offers models, named by Name and described by
+// Description, in the selector without its details pane.
+func RunModelSelector(entries []Entry) (string, error) {
+	if len(entries) == 0 {
+		return "", fmt.Errorf("no models found")
+	}
+
+	return runTviewSelector(entries, modelKind, nil)
+}
+
+// runTviewSelector runs the selector. setup, when not nil, is called with the
+// application before it runs; tests use it to install a simulation screen.
+func runTviewSelector(entries []Entry, kind selectorKind, setup func(app *tview.Application)) (result string, err error) {
 	const (
-		minFlexWidth      = 30
+		minFlexWidth      = 50
 		maxFlexWidth      = 80
-		flexHeightPadding = 4 // title line (1) + top padding (1) + bottom padding (1) + buffer (1) = 4
+		flexHeightPadding = 5 // title line (1) + filter line (1) + top padding (1) + bottom padding (1) + buffer (1) = 5
 	)
+	detailsHeight := detailsLines + 2 // details plus its border
+	if !kind.details {
+		detailsHeight = 0
+	}
 
 	var app *tview.Application
 
@@ -44,32 +94,106 @@ func runTviewSelector(contexts []string) (result string, err error) {
 	flex := tview.NewFlex().SetDirection(tview.FlexRow)
 
 	title := tview.NewTextView().
-		SetText("Select a context to run with (ESC to cancel)").
+		SetText(kind.title).
 		SetTextColor(tview.Styles.SecondaryTextColor).
 		SetTextAlign(tview.AlignLeft)
 
+	filter := tview.NewInputField().
+		SetLabel("/ ").
+		SetPlaceholder("type / to filter by name, tag or description").
+		SetFieldBackgroundColor(tview.Styles.PrimitiveBackgroundColor).
+		SetPlaceholderStyle(tcell.StyleDefault.Foreground(tview.Styles.TertiaryTextColor))
+
 	list := tview.NewList().ShowSecondaryText(false)
 
-	for _, ctx := range contexts {
-		list.AddItem(ctx, "", 0, nil)
+	detailsView := tview.NewTextView().SetDynamicColors(true).SetWrap(false)
+	detailsView.SetBorder(true).SetTitleAlign(tview.AlignLeft)
+
+	// visible holds the entries currently shown, in list order
+	var visible []match
+	showDetails := func(index int) {
+		if index < 0 || index >= len(visible) {
+			detailsView.SetTitle("")
+			detailsView.SetText("No matching " + kind.noun + "s")
+			return
+		}
+		e := visible[index].entry
+		detailsView.SetTitle(" " + tview.Escape(e.Name) + " ")
+		detailsView.SetText(details(e, time.Now()))
 	}
+	refresh := func() {
+		visible = filterEntries(entries, filter.GetText())
+		list.Clear()
+		for _, m := range visible {
+			list.AddItem(m.text(), "", 0, nil)
+		}
+		showDetails(list.GetCurrentItem())
+	}
+	refresh()
 
 	flex.AddItem(title, 1, 0, false).
+		AddItem(filter, 1, 0, false).
 		AddItem(list, 0, 1, true)
+	if kind.details {
+		flex.AddItem(detailsView, detailsHeight, 0, false)
+	}
 
-	maxItems := min(len(contexts), 10)
+	maxItems := min(len(entries), 10)
 
-	maxNameLen := 0
-	for _, ctx := range contexts {
-		if utf8.RuneCountInString(ctx) > maxNameLen {
-			maxNameLen = utf8.RuneCountInString(ctx)
-		}
+	maxRowWidth := utf8.RuneCountInString(title.GetText(false))
+	for _, m := range visible {
+		maxRowWidth = max(maxRowWidth, m.plainWidth())
 	}
-	flexWidth := min(max(maxNameLen+6, minFlexWidth), maxFlexWidth)
-	flex.SetRect(0, 1, flexWidth, maxItems+flexHeightPadding)
+	flexWidth := min(max(maxRowWidth+6, minFlexWidth), maxFlexWidth)
+	flex.SetRect(0, 1, flexWidth, maxItems+flexHeightPadding+detailsHeight)
 
 	var selectedContext string
 
+	choose := func(index int) {
+		if index >= 0 && index < len(visible) {
+			selectedContext = visible[index].entry.Name
+			app.Stop()
+		}
+	}
+	move := func(delta int) {
+		index := list.GetCurrentItem() + delta
+		if index >= 0 && index < list.GetItemCount() {
+			list.SetCurrentItem(index)
+		}
+	}
+
+	list.SetChangedFunc(func(index int, _ string, _ string, _ rune) {
+		showDetails(index)
+	})
+
+	filter.SetChangedFunc(func(string) {
+		refresh()
+	})
+
+	// While filtering, the arrow keys still move through the results, Enter
+	// picks one, Tab returns to the list and ESC clears the filter.
+	filter.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyUp, tcell.KeyCtrlP:
+			move(-1)
+			return nil
+		case tcell.KeyDown, tcell.KeyCtrlN:
+			move(1)
+			return nil
+		case tcell.KeyEnter:
+			choose(list.GetCurrentItem())
+			return nil
+		case tcell.KeyTab:
+			app.SetFocus(list)
+			return nil
+		case tcell.KeyEscape:
+			filter.SetText("")
+			app.SetFocus(list)
+			return nil
+		}
+		return event
+	})
+
 	list.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
 		switch event.Key() {
 		case tcell.KeyEscape:
@@ -78,16 +202,13 @@ func runTviewSelector(contexts []string) (result string, err error) {
 		case tcell.KeyRune:
 			switch event.Rune() {
 			case 'j':
-				currentIndex := list.GetCurrentItem()
-				if currentIndex < list.GetItemCount()-1 {
-					list.SetCurrentItem(currentIndex + 1)
-				}
+				move(1)
 				return nil
 			case 'k':
-				currentIndex := list.GetCurrentItem()
-				if currentIndex > 0 {
-					list.SetCurrentItem(currentIndex - 1)
-				}
+				move(-1)
+				return nil
+			case '/':
+				app.SetFocus(filter)
 				return nil
 			}
 		}
@@ -95,16 +216,18 @@ func runTviewSelector(contexts []string) (result string, err error) {
 	})
 
 	list.SetDoneFunc(func() {
-		selectedContext = contexts[list.GetCurrentItem()]
-		app.Stop()
+		choose(list.GetCurrentItem())
 	})
 
 	list.SetSelectedFunc(func(index int, _ string, _ string, _ rune) {
-		selectedContext = contexts[index]
-		app.Stop()
+		choose(index)
 	})
 
-	if runErr := app.SetRoot(flex, false).SetFocus(list).Run(); runErr != nil {
+	app.SetRoot(flex, false).SetFocus(list)
+	if setup != nil {
+		setup(app)
+	}
+	if runErr := app.Run(); runErr != nil {
 		app.Stop()
 		return "", runErr
 	}
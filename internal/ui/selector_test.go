@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -48,7 +50,92 @@ func TestErrCancelled(t *testing.T) {
 }
 
 func TestRunContextSelector_EmptyContexts(t *testing.T) {
-	_, err := RunContextSelector([]string{})
+	_, err := RunContextSelector([]Entry{})
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "no contexts found")
 }
+
+func TestRunTviewSelector_Keys(t *testing.T) {
+	entries := []Entry{
+		{Name: "alpha"},
+		{Name: "beta", Tags: []string{"work"}},
+		{Name: "gamma", Description: "Work proxy"},
+		{Name: "work"},
+	}
+
+	key := func(k tcell.Key) tcell.Event { return tcell.NewEventKey(k, 0, tcell.ModNone) }
+	runes := func(s string) []tcell.Event {
+		var events []tcell.Event
+		for _, r := range s {
+			events = append(events, tcell.NewEventKey(tcell.KeyRune, r, tcell.ModNone))
+		}
+		return events
+	}
+
+	tests := []struct {
+		name    string
+		events  []tcell.Event
+		want    string
+		wantErr error
+	}{
+		{
+			name:   "enter picks the first context",
+			events: []tcell.Event{key(tcell.KeyEnter)},
+			want:   "alpha",
+		},
+		{
+			name:   "j and k move",
+			events: append(runes("jjk"), key(tcell.KeyEnter)),
+			want:   "beta",
+		},
+		{
+			name:   "filter then enter picks the best match",
+			events: append(runes("/work"), key(tcell.KeyEnter)),
+			want:   "work",
+		},
+		{
+			name:   "arrows move within the filtered results",
+			events: append(runes("/work"), key(tcell.KeyDown), key(tcell.KeyEnter)),
+			want:   "beta",
+		},
+		{
+			name:   "j and k work on the filtered results after tab",
+			events: append(append(runes("/work"), key(tcell.KeyTab)), append(runes("jj"), key(tcell.KeyEnter))...),
+			want:   "gamma",
+		},
+		{
+			name:   "escape in the filter clears it",
+			events: append(runes("/work"), key(tcell.KeyEscape), key(tcell.KeyEnter)),
+			want:   "alpha",
+		},
+		{
+			name:    "enter with no matches does nothing",
+			events:  append(runes("/xyz"), key(tcell.KeyEnter), key(tcell.KeyEscape), key(tcell.KeyEscape)),
+			wantErr: ErrCancelled,
+		},
+		{
+			name:    "escape cancels",
+			events:  []tcell.Event{key(tcell.KeyEscape)},
+			wantErr: ErrCancelled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := runTviewSelector(entries, contextKind, func(app *tview.Application) {
+				screen := tcell.NewSimulationScreen("UTF-8")
+				screen.SetSize(80, 24)
+				app.SetScreen(screen)
+				for _, event := range tt.events {
+					app.QueueEvent(event)
+				}
+			})
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
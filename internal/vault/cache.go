@@ -0,0 +1,116 @@
+package vault
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/dsdashun/ccctx/internal/fsutil"
+)
+
+// defaultCacheTTL is how long an unlocked vault key is reused before the
+// passphrase is asked for again.
+const defaultCacheTTL = 15 * time.Minute
+
+type cachedKey struct {
+	Salt    []byte    `json:"salt"`
+	Key     []byte    `json:"key"`
+	Expires time.Time `json:"expires"`
+}
+
+// cacheTTL reads $CCCTX_VAULT_TIMEOUT; "0" disables the unlock cache.
+func cacheTTL() time.Duration {
+	if v := os.Getenv("CCCTX_VAULT_TIMEOUT"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d >= 0 {
+			return d
+		}
+	}
+	return defaultCacheTTL
+}
+
+// errNoRuntimeDir means there is nowhere safe to cache a key.
+var errNoRuntimeDir = errors.New("XDG_RUNTIME_DIR is not set")
+
+// cachePath places the key cache in $XDG_RUNTIME_DIR, which is per-user,
+// usually memory-backed and cleared at logout. Without it keys are not
+// cached: a persistent directory would keep the key on disk, next to the
+// vault it decrypts, past its expiry. The file name is derived from the vault
+// path so separate vaults don't share a key.
+func cachePath(vaultPath string) (string, error) {
+	dir := os.Getenv("XDG_RUNTIME_DIR")
+	if dir == "" {
+		return "", errNoRuntimeDir
+	}
+	if abs, err := filepath.Abs(vaultPath); err == nil {
+		vaultPath = abs
+	}
+	sum := sha256.Sum256([]byte(vaultPath))
+	return filepath.Join(dir, "ccctx", "vault-"+hex.EncodeToString(sum[:8])+".key"), nil
+}
+
+func loadCachedKey(vaultPath string, salt []byte) []byte {
+	path, err := cachePath(vaultPath)
+	if err != nil {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var cached cachedKey
+	if err := json.Unmarshal(data, &cached); err != nil {
+		return nil
+	}
+	if !bytes.Equal(cached.Salt, salt) || time.Now().After(cached.Expires) {
+		os.Remove(path)
+		return nil
+	}
+	return cached.Key
+}
+
+// storeCachedKey is best effort: failing to cache only means prompting again.
+func storeCachedKey(vaultPath string, salt, key []byte) {
+	ttl := cacheTTL()
+	if ttl == 0 {
+		return
+	}
+	path, err := cachePath(vaultPath)
+	if err != nil {
+		return
+	}
+	data, err := json.Marshal(cachedKey{Salt: salt, Key: key, Expires: time.Now().Add(ttl)})
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return
+	}
+	_ = fsutil.WriteFileAtomic(path, data, 0600)
+}
+
+func clearCachedKey(vaultPath string) {
+	if path, err := cachePath(vaultPath); err == nil {
+		os.Remove(path)
+	}
+}
+
+// Lock forgets the cached key for the vault at path, so the next access
+// prompts for the passphrase again.
+func Lock(path string) error {
+	cache, err := cachePath(path)
+	if errors.Is(err, errNoRuntimeDir) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(cache); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package vault
+
+import (
+	"fmt"
+	"os"
+
+	"golang.org/x/term"
+)
+
+// ReadPassphrase prompts for a passphrase without echoing it. It reads from
+// the controlling terminal so it works even when stdin is redirected.
+var ReadPassphrase = readPassphraseTTY
+
+// passphrase returns $CCCTX_VAULT_PASSPHRASE when set, for non-interactive
+// use, and prompts otherwise.
+func passphrase(prompt string) (string, error) {
+	if p := os.Getenv("CCCTX_VAULT_PASSPHRASE"); p != "" {
+		return p, nil
+	}
+	p, err := ReadPassphrase(prompt)
+	if err != nil {
+		return "", err
+	}
+	if p == "" {
+		return "", fmt.Errorf("vault passphrase cannot be empty")
+	}
+	return p, nil
+}
+
+// newPassphrase asks for a passphrase twice so typos don't lock the vault.
+func newPassphrase(prompt string) (string, error) {
+	if p := os.Getenv("CCCTX_VAULT_PASSPHRASE"); p != "" {
+		return p, nil
+	}
+	return confirmedPassphrase(prompt)
+}
+
+// rekeyPassphrase returns $CCCTX_VAULT_NEW_PASSPHRASE when set and asks for
+// the new passphrase twice otherwise. $CCCTX_VAULT_PASSPHRASE holds the
+// current passphrase, so it is not used.
+func rekeyPassphrase(prompt string) (string, error) {
+	if p := os.Getenv("CCCTX_VAULT_NEW_PASSPHRASE"); p != "" {
+		return p, nil
+	}
+	return confirmedPassphrase(prompt)
+}
+
+func confirmedPassphrase(prompt string) (string, error) {
+	p, err := ReadPassphrase(prompt)
+	if err != nil {
+		return "", err
+	}
+	if p == "" {
+		return "", fmt.Errorf("vault passphrase cannot be empty")
+	}
+	confirm, err := ReadPassphrase("Confirm passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	if p != confirm {
+		return "", ErrPassphraseMismatch
+	}
+	return p, nil
+}
+
+func readPassphraseTTY(prompt string) (string, error) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		tty = os.Stdin
+	} else {
+		defer tty.Close()
+	}
+	if !term.IsTerminal(int(tty.Fd())) {
+		return "", fmt.Errorf("no terminal to read the vault passphrase from; set CCCTX_VAULT_PASSPHRASE, or CCCTX_VAULT_NEW_PASSPHRASE for rekey")
+	}
+
+	fmt.Fprint(os.Stderr, prompt)
+	p, err := term.ReadPassword(int(tty.Fd()))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	return string(p), nil
+}
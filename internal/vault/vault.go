@@ -0,0 +1,274 @@
+package vault
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+
+	"github.com/dsdashun/ccctx/internal/fsutil"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	fileVersion = 1
+	keyLen      = 32
+	saltLen     = 16
+
+	// scrypt parameters recommended for interactive logins
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+)
+
+// additionalData binds ciphertexts to this file format.
+var additionalData = []byte("ccctx-vault-v1")
+
+var (
+	ErrNotFound           = errors.New("secret not found in vault")
+	ErrWrongPassphrase    = errors.New("wrong vault passphrase")
+	ErrPassphraseMismatch = errors.New("passphrases do not match")
+	ErrSamePassphrase     = errors.New("new vault passphrase is the same as the current one")
+)
+
+type file struct {
+	Version int    `json:"version"`
+	KDF     string `json:"kdf"`
+	N       int    `json:"n"`
+	R       int    `json:"r"`
+	P       int    `json:"p"`
+	Salt    []byte `json:"salt"`
+	Nonce   []byte `json:"nonce"`
+	Data    []byte `json:"data"`
+}
+
+// Vault is a passphrase-encrypted set of named secrets stored in one file.
+// Secrets are only accessible after Unlock.
+type Vault struct {
+	path    string
+	file    *file
+	key     []byte
+	secrets map[string]string
+}
+
+// Open reads the vault at path without decrypting it. A missing file yields
+// an empty vault that is created on the first Save.
+func Open(path string) (*Vault, error) {
+	v := &Vault{path: path}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return v, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var f file
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse vault file: %w", err)
+	}
+	if f.Version != fileVersion || f.KDF != "scrypt" {
+		return nil, fmt.Errorf("unsupported vault file version %d (%s)", f.Version, f.KDF)
+	}
+	v.file = &f
+	return v, nil
+}
+
+// Exists reports whether the vault file was present when opened.
+func (v *Vault) Exists() bool {
+	return v.file != nil
+}
+
+// Unlock decrypts the vault, reusing a cached key when one is still valid and
+// prompting for the passphrase otherwise. Unlocking a vault that does not
+// exist yet asks for a new passphrase instead.
+func (v *Vault) Unlock() error {
+	if v.secrets != nil {
+		return nil
+	}
+	if v.file == nil {
+		pass, err := newPassphrase("New vault passphrase: ")
+		if err != nil {
+			return err
+		}
+		return v.init(pass)
+	}
+
+	if key := loadCachedKey(v.path, v.file.Salt); key != nil {
+		if err := v.decrypt(key); err == nil {
+			return nil
+		}
+		clearCachedKey(v.path)
+	}
+
+	pass, err := passphrase("Vault passphrase: ")
+	if err != nil {
+		return err
+	}
+	key, err := scrypt.Key([]byte(pass), v.file.Salt, v.file.N, v.file.R, v.file.P, keyLen)
+	if err != nil {
+		return err
+	}
+	if err := v.decrypt(key); err != nil {
+		return err
+	}
+	storeCachedKey(v.path, v.file.Salt, key)
+	return nil
+}
+
+func (v *Vault) init(pass string) error {
+	salt := make([]byte, saltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+	key, err := scrypt.Key([]byte(pass), salt, scryptN, scryptR, scryptP, keyLen)
+	if err != nil {
+		return err
+	}
+	v.file = &file{Version: fileVersion, KDF: "scrypt", N: scryptN, R: scryptR, P: scryptP, Salt: salt}
+	v.key = key
+	if v.secrets == nil {
+		v.secrets = map[string]string{}
+	}
+	return nil
+}
+
+func (v *Vault) decrypt(key []byte) error {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return err
+	}
+	plaintext, err := gcm.Open(nil, v.file.Nonce, v.file.Data, additionalData)
+	if err != nil {
+		return ErrWrongPassphrase
+	}
+	secrets := map[string]string{}
+	if err := json.Unmarshal(plaintext, &secrets); err != nil {
+		return fmt.Errorf("failed to decode vault contents: %w", err)
+	}
+	v.key = key
+	v.secrets = secrets
+	return nil
+}
+
+func (v *Vault) unlocked() error {
+	if v.secrets == nil {
+		return fmt.Errorf("vault is locked")
+	}
+	return nil
+}
+
+// Get returns the named secret.
+func (v *Vault) Get(name string) (string, error) {
+	if err := v.unlocked(); err != nil {
+		return "", err
+	}
+	secret, exists := v.secrets[name]
+	if !exists {
+		return "", fmt.Errorf("'%s': %w", name, ErrNotFound)
+	}
+	return secret, nil
+}
+
+// Set stores secret under name. Call Save to persist it.
+func (v *Vault) Set(name, secret string) error {
+	if err := v.unlocked(); err != nil {
+		return err
+	}
+	if name == "" {
+		return fmt.Errorf("secret name cannot be empty")
+	}
+	v.secrets[name] = secret
+	return nil
+}
+
+// Delete removes the named secret. Call Save to persist the removal.
+func (v *Vault) Delete(name string) error {
+	if err := v.unlocked(); err != nil {
+		return err
+	}
+	if _, exists := v.secrets[name]; !exists {
+		return fmt.Errorf("'%s': %w", name, ErrNotFound)
+	}
+	delete(v.secrets, name)
+	return nil
+}
+
+// Names returns the names of all stored secrets in sorted order.
+func (v *Vault) Names() ([]string, error) {
+	if err := v.unlocked(); err != nil {
+		return nil, err
+	}
+	return slices.Sorted(maps.Keys(v.secrets)), nil
+}
+
+// Rekey re-encrypts the vault under a new passphrase, taken from
+// $CCCTX_VAULT_NEW_PASSPHRASE or prompted for, and drops any cached key for
+// the old one. Call Save to persist it. Keeping the current passphrase is an
+// error.
+func (v *Vault) Rekey() error {
+	if err := v.unlocked(); err != nil {
+		return err
+	}
+	pass, err := rekeyPassphrase("New vault passphrase: ")
+	if err != nil {
+		return err
+	}
+	key, err := scrypt.Key([]byte(pass), v.file.Salt, v.file.N, v.file.R, v.file.P, keyLen)
+	if err != nil {
+		return err
+	}
+	if subtle.ConstantTimeCompare(key, v.key) == 1 {
+		return ErrSamePassphrase
+	}
+	clearCachedKey(v.path)
+	return v.init(pass)
+}
+
+// Save encrypts the secrets with a fresh nonce and atomically replaces the
+// vault file, keeping it readable by the owner only.
+func (v *Vault) Save() error {
+	if err := v.unlocked(); err != nil {
+		return err
+	}
+	plaintext, err := json.Marshal(v.secrets)
+	if err != nil {
+		return err
+	}
+	gcm, err := newGCM(v.key)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	f := *v.file
+	f.Nonce = nonce
+	f.Data = gcm.Seal(nil, nonce, plaintext, additionalData)
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := fsutil.WriteFileAtomic(v.path, data, 0600); err != nil {
+		return err
+	}
+	v.file = &f
+	storeCachedKey(v.path, f.Salt, v.key)
+	return nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,243 @@
+package vault
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubPassphrases answers prompts from answers in order and counts them.
+func stubPassphrases(t *testing.T, answers ...string) *int {
+	t.Helper()
+	t.Setenv("CCCTX_VAULT_PASSPHRASE", "")
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	prompts := 0
+	original := ReadPassphrase
+	ReadPassphrase = func(string) (string, error) {
+		if prompts >= len(answers) {
+			return "", errors.New("unexpected prompt")
+		}
+		prompts++
+		return answers[prompts-1], nil
+	}
+	t.Cleanup(func() { ReadPassphrase = original })
+	return &prompts
+}
+
+func createVault(t *testing.T, path, pass string, secrets map[string]string) {
+	t.Helper()
+	v, err := Open(path)
+	require.NoError(t, err)
+	original := ReadPassphrase
+	ReadPassphrase = func(string) (string, error) { return pass, nil }
+	defer func() { ReadPassphrase = original }()
+	require.NoError(t, v.Unlock())
+	for name, secret := range secrets {
+		require.NoError(t, v.Set(name, secret))
+	}
+	require.NoError(t, v.Save())
+	require.NoError(t, Lock(path))
+}
+
+func TestVault_RoundTrip(t *testing.T) {
+	stubPassphrases(t)
+	path := filepath.Join(t.TempDir(), "vault.json")
+	createVault(t, path, "correct horse", map[string]string{"work": "work-secret", "personal": "personal-secret"})
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "work-secret")
+
+	prompts := stubPassphrases(t, "correct horse")
+	v, err := Open(path)
+	require.NoError(t, err)
+	require.NoError(t, v.Unlock())
+	assert.Equal(t, 1, *prompts)
+
+	secret, err := v.Get("work")
+	require.NoError(t, err)
+	assert.Equal(t, "work-secret", secret)
+
+	names, err := v.Names()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"personal", "work"}, names)
+
+	_, err = v.Get("missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestVault_Unlock(t *testing.T) {
+	tests := []struct {
+		name        string
+		answers     []string
+		envPass     string
+		timeout     string
+		noRuntime   bool
+		unlockTwice bool
+		wantPrompts int
+		wantErr     error
+	}{
+		{
+			name:        "wrong passphrase",
+			answers:     []string{"wrong"},
+			wantPrompts: 1,
+			wantErr:     ErrWrongPassphrase,
+		},
+		{
+			name:        "passphrase from environment skips the prompt",
+			envPass:     "correct horse",
+			wantPrompts: 0,
+		},
+		{
+			name:        "cached key avoids a second prompt",
+			answers:     []string{"correct horse"},
+			unlockTwice: true,
+			wantPrompts: 1,
+		},
+		{
+			name:        "zero timeout disables the cache",
+			answers:     []string{"correct horse", "correct horse"},
+			timeout:     "0",
+			unlockTwice: true,
+			wantPrompts: 2,
+		},
+		{
+			name:        "no runtime directory disables the cache",
+			answers:     []string{"correct horse", "correct horse"},
+			noRuntime:   true,
+			unlockTwice: true,
+			wantPrompts: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "vault.json")
+			prompts := stubPassphrases(t, tt.answers...)
+			createVault(t, path, "correct horse", map[string]string{"work": "work-secret"})
+			t.Setenv("CCCTX_VAULT_PASSPHRASE", tt.envPass)
+			t.Setenv("CCCTX_VAULT_TIMEOUT", tt.timeout)
+			if tt.noRuntime {
+				t.Setenv("XDG_RUNTIME_DIR", "")
+			}
+
+			attempts := 1
+			if tt.unlockTwice {
+				attempts = 2
+			}
+			var err error
+			for i := 0; i < attempts; i++ {
+				var v *Vault
+				v, err = Open(path)
+				require.NoError(t, err)
+				if err = v.Unlock(); err != nil {
+					break
+				}
+			}
+
+			assert.Equal(t, tt.wantPrompts, *prompts)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestVault_NewPassphraseMismatch(t *testing.T) {
+	stubPassphrases(t, "one", "two")
+	v, err := Open(filepath.Join(t.TempDir(), "vault.json"))
+	require.NoError(t, err)
+	assert.False(t, v.Exists())
+	assert.ErrorIs(t, v.Unlock(), ErrPassphraseMismatch)
+}
+
+func TestVault_Rekey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vault.json")
+	stubPassphrases(t)
+	createVault(t, path, "old pass", map[string]string{"work": "work-secret"})
+
+	stubPassphrases(t, "old pass", "new pass", "new pass")
+	v, err := Open(path)
+	require.NoError(t, err)
+	require.NoError(t, v.Unlock())
+	require.NoError(t, v.Rekey())
+	require.NoError(t, v.Save())
+	require.NoError(t, Lock(path))
+
+	stubPassphrases(t, "old pass")
+	v, err = Open(path)
+	require.NoError(t, err)
+	assert.ErrorIs(t, v.Unlock(), ErrWrongPassphrase)
+
+	stubPassphrases(t, "new pass")
+	v, err = Open(path)
+	require.NoError(t, err)
+	require.NoError(t, v.Unlock())
+	secret, err := v.Get("work")
+	require.NoError(t, err)
+	assert.Equal(t, "work-secret", secret)
+}
+
+func TestVault_RekeyFromEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		newPass string
+		answers []string
+		wantErr error
+	}{
+		{name: "new passphrase from the environment", newPass: "new pass"},
+		{name: "current passphrase from the environment", newPass: "old pass", wantErr: ErrSamePassphrase},
+		{name: "prompts instead of reusing CCCTX_VAULT_PASSPHRASE", answers: []string{"new pass", "new pass"}},
+		{name: "current passphrase typed again", answers: []string{"old pass", "old pass"}, wantErr: ErrSamePassphrase},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "vault.json")
+			stubPassphrases(t)
+			createVault(t, path, "old pass", map[string]string{"work": "work-secret"})
+
+			prompts := stubPassphrases(t, tt.answers...)
+			t.Setenv("CCCTX_VAULT_PASSPHRASE", "old pass")
+			t.Setenv("CCCTX_VAULT_NEW_PASSPHRASE", tt.newPass)
+			v, err := Open(path)
+			require.NoError(t, err)
+			require.NoError(t, v.Unlock())
+			err = v.Rekey()
+			assert.Equal(t, len(tt.answers), *prompts)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.NoError(t, v.Save())
+			require.NoError(t, Lock(path))
+
+			t.Setenv("CCCTX_VAULT_PASSPHRASE", "new pass")
+			v, err = Open(path)
+			require.NoError(t, err)
+			require.NoError(t, v.Unlock())
+			secret, err := v.Get("work")
+			require.NoError(t, err)
+			assert.Equal(t, "work-secret", secret)
+		})
+	}
+}
+
+func TestVault_Locked(t *testing.T) {
+	v, err := Open(filepath.Join(t.TempDir(), "vault.json"))
+	require.NoError(t, err)
+	_, err = v.Get("work")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "vault is locked")
+}
@@ -18,6 +18,21 @@ func init() {
 	rootCmd.AddCommand(cmd.ListCmd)
 	rootCmd.AddCommand(cmd.RunCmd)
 	rootCmd.AddCommand(cmd.ExecCmd)
+	rootCmd.AddCommand(cmd.VaultCmd)
+	rootCmd.AddCommand(cmd.UseCmd)
+	rootCmd.AddCommand(cmd.CurrentCmd)
+	rootCmd.AddCommand(cmd.AddCmd)
+	rootCmd.AddCommand(cmd.RmCmd)
+	rootCmd.AddCommand(cmd.RenameCmd)
+	rootCmd.AddCommand(cmd.CpCmd)
+	rootCmd.AddCommand(cmd.SetCmd)
+	rootCmd.AddCommand(cmd.UnsetCmd)
+	rootCmd.AddCommand(cmd.ShowCmd)
+	rootCmd.AddCommand(cmd.EnvCmd)
+	rootCmd.AddCommand(cmd.HistoryCmd)
+	rootCmd.AddCommand(cmd.DoctorCmd)
+	rootCmd.AddCommand(cmd.PingCmd)
+	rootCmd.AddCommand(cmd.ModelsCmd)
 }
 
 func main() {
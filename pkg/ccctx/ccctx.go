@@ -0,0 +1,206 @@
+// Package ccctx lets Go programs use ccctx contexts: load a config file,
+// resolve a context's settings and secrets, and run commands with the
+// environment 'ccctx exec' would give them.
+//
+//	cfg, err := ccctx.Load("")
+//	if err != nil {
+//		return err
+//	}
+//	r, err := cfg.NewRunner("work", []string{"claude", "-p", "hello"}, ccctx.RunOptions{Stdout: &out})
+//	if err != nil {
+//		return err
+//	}
+//	code, err := r.Run(ctx)
+package ccctx
+
+import (
+	"context"
+	"io"
+	"maps"
+	"slices"
+
+	"github.com/dsdashun/ccctx/config"
+	"github.com/dsdashun/ccctx/internal/runner"
+)
+
+// Config is a loaded config file. It is not refreshed when the file changes;
+// load it again to see edits.
+type Config struct {
+	cfg *config.Config
+}
+
+// loader keeps the files Load parsed, so loading an unchanged file again is
+// cheap.
+var loader = config.NewLoader()
+
+// DefaultPath returns the config file the CLI uses: $CCCTX_CONFIG_PATH, or
+// ~/.ccctx/config.toml.
+func DefaultPath() (string, error) {
+	return config.GetConfigPath()
+}
+
+// Load reads the config file at path, or at DefaultPath when path is empty.
+// Unlike the CLI it does not create a missing file.
+func Load(path string) (*Config, error) {
+	if path == "" {
+		var err error
+		path, err = DefaultPath()
+		if err != nil {
+			return nil, err
+		}
+	}
+	cfg, err := loader.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Config{cfg: cfg}, nil
+}
+
+// Path returns the file c was loaded from.
+func (c *Config) Path() string {
+	return c.cfg.Path
+}
+
+// Contexts returns the names of the contexts in c, sorted.
+func (c *Config) Contexts() []string {
+	return slices.Sorted(maps.Keys(c.cfg.Contexts))
+}
+
+// Context is a context with its extends chain and defaults applied and its
+// secret references resolved.
+type Context struct {
+	Name        string
+	Description string
+	Tags        []string
+
+	// Provider is "anthropic", "bedrock" or "vertex". BaseURL and AuthToken
+	// serve anthropic contexts, the AWS and Vertex fields the other two.
+	Provider        string
+	BaseURL         string
+	AuthToken       string
+	AWSRegion       string
+	AWSProfile      string
+	VertexRegion    string
+	VertexProjectID string
+
+	// Model, HaikuModel, SonnetModel and OpusModel are model IDs, with the
+	// aliases of [models] tables expanded as the runner expands them.
+	Model       string
+	HaikuModel  string
+	SonnetModel string
+	OpusModel   string
+
+	// Env holds the extra variables the context sets.
+	Env map[string]string
+	// Unset lists the inherited variables the context removes.
+	Unset []string
+}
+
+// Resolve returns the named context. Resolving runs the secret resolvers its
+// values refer to, which may read files, run commands or unlock the vault.
+func (c *Config) Resolve(name string) (*Context, error) {
+	ctx, err := c.cfg.Context(name)
+	if err != nil {
+		return nil, err
+	}
+	expand := func(value string) string {
+		model, _ := ctx.ExpandModel(value)
+		return model
+	}
+	haiku := ctx.HaikuModel
+	if haiku == "" {
+		haiku = ctx.SmallFastModel
+	}
+	return &Context{
+		Name:            name,
+		Description:     ctx.Description,
+		Tags:            slices.Clone(ctx.Tags),
+		Provider:        ctx.ProviderName(),
+		BaseURL:         ctx.BaseURL,
+		AuthToken:       ctx.AuthToken,
+		AWSRegion:       ctx.AWSRegion,
+		AWSProfile:      ctx.AWSProfile,
+		VertexRegion:    ctx.VertexRegion,
+		VertexProjectID: ctx.VertexProjectID,
+		Model:           expand(ctx.Model),
+		HaikuModel:      expand(haiku),
+		SonnetModel:     expand(ctx.SonnetModel),
+		OpusModel:       expand(ctx.OpusModel),
+		Env:             ctx.Env,
+		Unset:           slices.Clone(ctx.Unset),
+	}, nil
+}
+
+// RunOptions adjusts how a Runner runs its command. The zero value runs it as
+// 'ccctx exec' would, minus signal forwarding.
+type RunOptions struct {
+	// Model, HaikuModel, SonnetModel and OpusModel override the context's
+	// models, like the flags of the same names.
+	Model       string
+	HaikuModel  string
+	SonnetModel string
+	OpusModel   string
+
+	// Env is the environment the context is applied to, in os.Environ form.
+	// Nil means the environment of the current process.
+	Env []string
+	// Dir is the command's working directory; empty means the current one.
+	Dir string
+	// Stdin, Stdout and Stderr are the command's standard streams. Nil means
+	// those of the current process.
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+	// ForwardSignals relays the signals the current process receives to the
+	// command while it runs.
+	ForwardSignals bool
+}
+
+// Runner runs a command with a context applied to its environment.
+type Runner struct {
+	r *runner.Runner
+}
+
+// NewRunner prepares command to run with the named context. The context must
+// set every field its provider requires, and a valid base_url when that is
+// one of them.
+func (c *Config) NewRunner(name string, command []string, opts RunOptions) (*Runner, error) {
+	r, err := runner.NewWithConfig(c.cfg, runner.Options{
+		ContextName: name,
+		Target:      command,
+		Model:       opts.Model,
+		HaikuModel:  opts.HaikuModel,
+		SonnetModel: opts.SonnetModel,
+		OpusModel:   opts.OpusModel,
+
+		Environ:        opts.Env,
+		Dir:            opts.Dir,
+		Stdin:          opts.Stdin,
+		Stdout:         opts.Stdout,
+		Stderr:         opts.Stderr,
+		ForwardSignals: opts.ForwardSignals,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Runner{r: r}, nil
+}
+
+// Command returns the command line r runs.
+func (r *Runner) Command() []string {
+	return r.r.Command()
+}
+
+// Env returns the environment r runs its command with.
+func (r *Runner) Env() []string {
+	return r.r.Environ()
+}
+
+// Run runs the command and returns its exit code, or 128 plus the signal
+// number if a signal killed it. An error means the command could not be
+// started, or that ctx was done before it finished; the command is then
+// sent SIGTERM, killed a few seconds later if still running, and ctx's error
+// is returned.
+func (r *Runner) Run(ctx context.Context) (int, error) {
+	return r.r.RunContext(ctx)
+}
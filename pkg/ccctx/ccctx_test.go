@@ -0,0 +1,142 @@
+package ccctx
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func TestLoad_SeveralFiles(t *testing.T) {
+	work, err := Load(writeConfig(t, "[context.work]\nbase_url = \"https://work.example.com\"\n"))
+	require.NoError(t, err)
+	personal, err := Load(writeConfig(t, "[context.personal]\nbase_url = \"https://personal.example.com\"\n"))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"work"}, work.Contexts())
+	assert.Equal(t, []string{"personal"}, personal.Contexts())
+
+	_, err = Load(filepath.Join(t.TempDir(), "missing.toml"))
+	assert.Error(t, err, "a missing file is not created")
+}
+
+func TestLoad_DefaultPath(t *testing.T) {
+	path := writeConfig(t, "[context.work]\nbase_url = \"https://work.example.com\"\n")
+	t.Setenv("CCCTX_CONFIG_PATH", path)
+
+	cfg, err := Load("")
+	require.NoError(t, err)
+	assert.Equal(t, path, cfg.Path())
+	assert.Equal(t, []string{"work"}, cfg.Contexts())
+}
+
+func TestResolve(t *testing.T) {
+	t.Setenv("WORK_TOKEN", "work-secret")
+	cfg, err := Load(writeConfig(t, `[models]
+opus = "claude-opus-4-7"
+
+[context.base]
+base_url = "https://gateway.example.com"
+small_fast_model = "base-haiku"
+
+[context.work]
+extends = "base"
+description = "Company gateway"
+tags = ["work"]
+auth_token = "env:WORK_TOKEN"
+model = "work-model"
+opus_model = "Opus"
+unset = ["HTTPS_PROXY"]
+
+[context.work.env]
+team = "platform"
+`))
+	require.NoError(t, err)
+
+	ctx, err := cfg.Resolve("work")
+	require.NoError(t, err)
+	assert.Equal(t, &Context{
+		Name:        "work",
+		Description: "Company gateway",
+		Provider:    "anthropic",
+		Tags:        []string{"work"},
+		BaseURL:     "https://gateway.example.com",
+		AuthToken:   "work-secret",
+		Model:       "work-model",
+		HaikuModel:  "base-haiku",
+		OpusModel:   "claude-opus-4-7",
+		Env:         map[string]string{"TEAM": "platform"},
+		Unset:       []string{"HTTPS_PROXY"},
+	}, ctx)
+
+	_, err = cfg.Resolve("missing")
+	assert.EqualError(t, err, "context 'missing' not found")
+}
+
+func TestRunner(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test commands need sh")
+	}
+	cfg, err := Load(writeConfig(t, "[context.work]\nbase_url = \"https://work.example.com\"\nauth_token = \"work-token\"\nmodel = \"work-model\"\n"))
+	require.NoError(t, err)
+
+	var stdout, stderr bytes.Buffer
+	dir := t.TempDir()
+	r, err := cfg.NewRunner("work", []string{"/bin/sh", "-c", `read line; echo "$line $ANTHROPIC_BASE_URL $ANTHROPIC_MODEL $KEEP $ANTHROPIC_API_KEY"; pwd; echo oops >&2; exit 3`}, RunOptions{
+		Model:  "override-model",
+		Env:    []string{"KEEP=kept", "ANTHROPIC_API_KEY=dropped", "PATH=" + os.Getenv("PATH")},
+		Dir:    dir,
+		Stdin:  strings.NewReader("hello\n"),
+		Stdout: &stdout,
+		Stderr: &stderr,
+	})
+	require.NoError(t, err)
+	assert.Contains(t, r.Env(), "ANTHROPIC_AUTH_TOKEN=work-token")
+	assert.NotContains(t, r.Env(), "ANTHROPIC_API_KEY=dropped")
+
+	code, err := r.Run(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 3, code)
+	wantDir, err := filepath.EvalSymlinks(dir)
+	require.NoError(t, err)
+	assert.Equal(t, "hello https://work.example.com override-model kept \n"+wantDir+"\n", stdout.String())
+	assert.Equal(t, "oops\n", stderr.String())
+}
+
+func TestRunner_Cancel(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test commands need sh")
+	}
+	cfg, err := Load(writeConfig(t, "[context.work]\nbase_url = \"https://work.example.com\"\nauth_token = \"work-token\"\n"))
+	require.NoError(t, err)
+	r, err := cfg.NewRunner("work", []string{"/bin/sh", "-c", "exec sleep 10"}, RunOptions{})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err = r.Run(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestNewRunner_Invalid(t *testing.T) {
+	cfg, err := Load(writeConfig(t, "[context.work]\nbase_url = \"https://work.example.com\"\n"))
+	require.NoError(t, err)
+	_, err = cfg.NewRunner("work", []string{"true"}, RunOptions{})
+	assert.EqualError(t, err, "context 'work' is missing auth_token")
+}